@@ -7,7 +7,13 @@ import (
 )
 
 func main() {
-	err := tfsdk.Serve(context.Background(), vaultsecure.New, tfsdk.ServeOpts{Name: "vaultsecure"})
+	ctx := context.Background()
+
+	// Keep a reference to the provider, so we can clean up after the server stopped. Terraform usually kills the
+	// provider process instead, so the cleanup is only best effort.
+	p := vaultsecure.New()
+	err := tfsdk.Serve(ctx, func() tfsdk.Provider { return p }, tfsdk.ServeOpts{Name: "vaultsecure"})
+	vaultsecure.Close(ctx, p)
 	if err != nil {
 		return
 	}
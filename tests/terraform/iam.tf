@@ -64,6 +64,11 @@ resource "aws_iam_role_policy" "testuser_management" {
       "Action": [
         "iam:CreateAccessKey",
         "iam:ListAccessKeys",
+        "iam:GetAccessKeyLastUsed",
+        "iam:UpdateAccessKey",
+        "iam:TagUser",
+        "iam:UntagUser",
+        "iam:ListUserTags",
         "iam:DeleteAccessKey",
         "iam:PutUserPolicy",
         "iam:GetUserPolicy",
@@ -1,6 +1,7 @@
 package vaultsecure
 
 import (
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
 	"math/rand"
 	"os"
 	"testing"
@@ -20,6 +21,14 @@ func testAccPreCheck(t *testing.T) {
 	}
 }
 
+// testAccSkipUnlessEnabled skips the test if acceptance tests are not enabled. It needs to be
+// called by all helpers that create test fixtures, as they run before resource.Test has a chance to skip
+func testAccSkipUnlessEnabled(t *testing.T) {
+	if os.Getenv(resource.EnvTfAcc) == "" {
+		t.Skipf("Acceptance tests skipped unless env '%s' set", resource.EnvTfAcc)
+	}
+}
+
 func addRandomSuffix(in string) string {
 	var letters = []rune("abcdefghijklmnopqrstuvwxyz0123456789")
 	b := make([]rune, 8)
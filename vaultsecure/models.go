@@ -10,7 +10,53 @@ type AwsSecretAccessKey struct {
 	AwsIamUsername           types.String `tfsdk:"aws_iam_username"`
 	AwsAccessKeyID           types.String `tfsdk:"aws_access_key_id"`
 	AwsAccessKeyCreationDate types.String `tfsdk:"aws_access_key_creation_date"`
+	Status                   types.String `tfsdk:"status"`
+	LastUsedDate             types.String `tfsdk:"last_used_date"`
+	LastUsedService          types.String `tfsdk:"last_used_service"`
+	LastUsedRegion           types.String `tfsdk:"last_used_region"`
+	Adopted                  types.Bool   `tfsdk:"adopted"`
 
+	RotationInterval types.String `tfsdk:"rotation_interval"`
+	RotationTriggers types.Map    `tfsdk:"rotation_triggers"`
+
+	VaultNamespace   types.String `tfsdk:"vault_namespace"`
 	VaultEnginePath  types.String `tfsdk:"vault_engine_path"`
 	VaultAccessKeyID types.String `tfsdk:"vault_access_key_id"`
+
+	Region           types.String `tfsdk:"region"`
+	IAMEndpoint      types.String `tfsdk:"iam_endpoint"`
+	STSEndpoint      types.String `tfsdk:"sts_endpoint"`
+	STSRegion        types.String `tfsdk:"sts_region"`
+	MaxRetries       types.Int64  `tfsdk:"max_retries"`
+	UsernameTemplate types.String `tfsdk:"username_template"`
+
+	RotationPeriod           types.Int64  `tfsdk:"rotation_period"`
+	RotationSchedule         types.String `tfsdk:"rotation_schedule"`
+	RotationWindow           types.Int64  `tfsdk:"rotation_window"`
+	DisableAutomatedRotation types.Bool   `tfsdk:"disable_automated_rotation"`
+
+	ExistingKeys  types.String `tfsdk:"existing_keys"`
+	AutoRepair    types.Bool   `tfsdk:"auto_repair"`
+	RotateOnAdopt types.Bool   `tfsdk:"rotate_on_adopt"`
+
+	OnDestroy                     types.String `tfsdk:"on_destroy"`
+	ClearRootCredentialsOnDestroy types.Bool   `tfsdk:"clear_root_credentials_on_destroy"`
+
+	ManageIAMUser *AwsSecretAccessKeyManagedIAMUser `tfsdk:"manage_iam_user"`
+
+	Timeouts *AwsSecretAccessKeyTimeouts `tfsdk:"timeouts"`
+}
+
+type AwsSecretAccessKeyManagedIAMUser struct {
+	Path                types.String `tfsdk:"path"`
+	PermissionsBoundary types.String `tfsdk:"permissions_boundary"`
+	Tags                types.Map    `tfsdk:"tags"`
+	PolicyStatements    types.List   `tfsdk:"policy_statements"`
+}
+
+type AwsSecretAccessKeyTimeouts struct {
+	Create types.String `tfsdk:"create"`
+	Read   types.String `tfsdk:"read"`
+	Update types.String `tfsdk:"update"`
+	Delete types.String `tfsdk:"delete"`
 }
@@ -3,13 +3,16 @@ package vaultsecure
 import (
 	"context"
 	"fmt"
-	awsConfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/iam"
 	"github.com/aws/aws-sdk-go-v2/service/sts"
 	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
 	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
 	vault "github.com/hashicorp/vault/api"
+	"net/http"
 )
 
 func New() tfsdk.Provider {
@@ -20,6 +23,9 @@ type provider struct {
 	iam   *iam.Client
 	sts   *sts.Client
 	vault *vault.Client
+
+	// childToken is set if the provider created a child token that needs to be revoked on shutdown
+	childToken string
 }
 
 func (p *provider) GetSchema(_ context.Context) (tfsdk.Schema, diag.Diagnostics) {
@@ -33,6 +39,258 @@ func (p *provider) GetSchema(_ context.Context) (tfsdk.Schema, diag.Diagnostics)
 				Type:     types.StringType,
 				Optional: true,
 			},
+			"vault_ca_cert_file": {
+				Type:        types.StringType,
+				Optional:    true,
+				Description: "Path to a PEM-encoded CA certificate file used to verify the Vault server certificate.",
+			},
+			"vault_ca_cert_dir": {
+				Type:        types.StringType,
+				Optional:    true,
+				Description: "Path to a directory of PEM-encoded CA certificate files used to verify the Vault server certificate.",
+			},
+			"vault_client_cert_file": {
+				Type:        types.StringType,
+				Optional:    true,
+				Description: "Path to a PEM-encoded client certificate for mutual TLS with the Vault server.",
+			},
+			"vault_client_key_file": {
+				Type:        types.StringType,
+				Optional:    true,
+				Description: "Path to the PEM-encoded private key of the client certificate.",
+			},
+			"vault_tls_server_name": {
+				Type:        types.StringType,
+				Optional:    true,
+				Description: "Name to use as the SNI host when connecting to the Vault server.",
+			},
+			"vault_skip_tls_verify": {
+				Type:        types.BoolType,
+				Optional:    true,
+				Description: "Disable verification of the Vault server certificate. Only use this for testing!",
+			},
+			"skip_credentials_validation": {
+				Type:     types.BoolType,
+				Optional: true,
+				Description: "Skip the validation of the AWS and Vault credentials during provider configuration " +
+					"(calls to sts:GetCallerIdentity and auth/token/lookup-self).",
+			},
+			"child_token": {
+				Optional: true,
+				Description: "Create a short-lived child token of the Vault token during provider configuration " +
+					"and use it for all operations. The child token can't be renewed and expires after its TTL, " +
+					"it is revoked earlier if the provider shuts down cleanly.",
+				Attributes: tfsdk.SingleNestedAttributes(map[string]tfsdk.Attribute{
+					"ttl": {
+						Type:        types.StringType,
+						Optional:    true,
+						Description: "TTL of the child token (defaults to `20m`).",
+						Validators: []tfsdk.AttributeValidator{
+							durationValidator{},
+						},
+					},
+					"policies": {
+						Type:        types.ListType{ElemType: types.StringType},
+						Optional:    true,
+						Description: "Policies of the child token (must be a subset of the policies of the parent token, defaults to all of them).",
+					},
+				}),
+			},
+
+			"aws": {
+				Optional:    true,
+				Description: "Configuration of the AWS credentials used to manage the access keys.",
+				Attributes: tfsdk.SingleNestedAttributes(map[string]tfsdk.Attribute{
+					"shared_config_files": {
+						Type:        types.ListType{ElemType: types.StringType},
+						Optional:    true,
+						Description: "List of paths to AWS shared config files.",
+					},
+					"shared_credentials_files": {
+						Type:        types.ListType{ElemType: types.StringType},
+						Optional:    true,
+						Description: "List of paths to AWS shared credentials files.",
+					},
+					"profile": {
+						Type:        types.StringType,
+						Optional:    true,
+						Description: "Name of the profile within the shared config and credentials files.",
+					},
+					"region": {
+						Type:     types.StringType,
+						Optional: true,
+						Description: "AWS region, which determines the partition of the IAM and STS endpoints " +
+							"(i.e. `us-gov-west-1` for `aws-us-gov`, defaults to `us-east-1`).",
+					},
+					"iam_endpoint": {
+						Type:        types.StringType,
+						Optional:    true,
+						Description: "Custom endpoint URL for IAM.",
+					},
+					"sts_endpoint": {
+						Type:        types.StringType,
+						Optional:    true,
+						Description: "Custom endpoint URL for STS.",
+					},
+					"assume_role": {
+						Optional:    true,
+						Description: "Role that should be assumed before managing the access keys.",
+						Attributes: tfsdk.SingleNestedAttributes(map[string]tfsdk.Attribute{
+							"role_arn": {
+								Type:        types.StringType,
+								Required:    true,
+								Description: "ARN of the role to assume.",
+							},
+							"session_name": {
+								Type:        types.StringType,
+								Optional:    true,
+								Description: "Name of the role session.",
+							},
+							"external_id": {
+								Type:        types.StringType,
+								Optional:    true,
+								Description: "External ID to pass when assuming the role.",
+							},
+							"session_tags": {
+								Type:        types.MapType{ElemType: types.StringType},
+								Optional:    true,
+								Description: "Session tags to pass when assuming the role.",
+							},
+							"duration": {
+								Type:        types.StringType,
+								Optional:    true,
+								Description: "Duration of the role session (i.e. `1h`, defaults to `15m`).",
+							},
+						}),
+					},
+					"assume_role_with_web_identity": {
+						Optional:    true,
+						Description: "Role that should be assumed with a web identity token before managing the access keys.",
+						Attributes: tfsdk.SingleNestedAttributes(map[string]tfsdk.Attribute{
+							"role_arn": {
+								Type:        types.StringType,
+								Required:    true,
+								Description: "ARN of the role to assume.",
+							},
+							"session_name": {
+								Type:        types.StringType,
+								Optional:    true,
+								Description: "Name of the role session.",
+							},
+							"web_identity_token": {
+								Type:        types.StringType,
+								Optional:    true,
+								Sensitive:   true,
+								Description: "The web identity token. Conflicts with `web_identity_token_file`.",
+							},
+							"web_identity_token_file": {
+								Type:        types.StringType,
+								Optional:    true,
+								Description: "Path to a file containing the web identity token. Conflicts with `web_identity_token`.",
+							},
+						}),
+					},
+				}),
+			},
+
+			"auth_login_approle": {
+				Optional:    true,
+				Description: "Login to Vault using the AppRole auth method.",
+				Attributes: tfsdk.SingleNestedAttributes(map[string]tfsdk.Attribute{
+					"mount": {
+						Type:        types.StringType,
+						Optional:    true,
+						Description: "Path where the AppRole auth method is mounted (defaults to `approle`).",
+					},
+					"role_id": {
+						Type:        types.StringType,
+						Required:    true,
+						Description: "RoleID of the AppRole.",
+					},
+					"secret_id": {
+						Type:        types.StringType,
+						Optional:    true,
+						Sensitive:   true,
+						Description: "SecretID of the AppRole. Can be omitted if the role does not require a SecretID.",
+					},
+				}),
+			},
+			"auth_login_jwt": {
+				Optional:    true,
+				Description: "Login to Vault using the JWT/OIDC auth method.",
+				Attributes: tfsdk.SingleNestedAttributes(map[string]tfsdk.Attribute{
+					"mount": {
+						Type:        types.StringType,
+						Optional:    true,
+						Description: "Path where the JWT auth method is mounted (defaults to `jwt`).",
+					},
+					"role": {
+						Type:        types.StringType,
+						Required:    true,
+						Description: "Name of the role to login with.",
+					},
+					"jwt": {
+						Type:        types.StringType,
+						Optional:    true,
+						Sensitive:   true,
+						Description: "The signed JWT to login with. Conflicts with `jwt_file`.",
+					},
+					"jwt_file": {
+						Type:        types.StringType,
+						Optional:    true,
+						Description: "Path to a file containing the signed JWT to login with. Conflicts with `jwt`.",
+					},
+				}),
+			},
+			"auth_login_kubernetes": {
+				Optional:    true,
+				Description: "Login to Vault using the Kubernetes auth method.",
+				Attributes: tfsdk.SingleNestedAttributes(map[string]tfsdk.Attribute{
+					"mount": {
+						Type:        types.StringType,
+						Optional:    true,
+						Description: "Path where the Kubernetes auth method is mounted (defaults to `kubernetes`).",
+					},
+					"role": {
+						Type:        types.StringType,
+						Required:    true,
+						Description: "Name of the role to login with.",
+					},
+					"jwt_file": {
+						Type:     types.StringType,
+						Optional: true,
+						Description: "Path to the file containing the service account token " +
+							"(defaults to `" + defaultKubernetesServiceAccountTokenFile + "`).",
+					},
+				}),
+			},
+			"auth_login_aws": {
+				Optional:    true,
+				Description: "Login to Vault using the AWS auth method (IAM), with the AWS credentials of the provider.",
+				Attributes: tfsdk.SingleNestedAttributes(map[string]tfsdk.Attribute{
+					"mount": {
+						Type:        types.StringType,
+						Optional:    true,
+						Description: "Path where the AWS auth method is mounted (defaults to `aws`).",
+					},
+					"role": {
+						Type:        types.StringType,
+						Optional:    true,
+						Description: "Name of the role to login with (defaults to the friendly name of the IAM principal).",
+					},
+					"header_value": {
+						Type:        types.StringType,
+						Optional:    true,
+						Description: "Value of the `X-Vault-AWS-IAM-Server-ID` header, if required by the auth method.",
+					},
+					"sts_region": {
+						Type:     types.StringType,
+						Optional: true,
+						Description: "Region of the STS endpoint the login request is signed for. Must match the STS endpoint " +
+							"configured in the auth method (defaults to the global endpoint in `us-east-1`).",
+					},
+				}),
+			},
 		},
 	}, nil
 }
@@ -41,6 +299,23 @@ func (p *provider) GetSchema(_ context.Context) (tfsdk.Schema, diag.Diagnostics)
 type providerData struct {
 	VaultAddress   types.String `tfsdk:"vault_address"`
 	VaultNamespace types.String `tfsdk:"vault_namespace"`
+
+	VaultCACertFile     types.String `tfsdk:"vault_ca_cert_file"`
+	VaultCACertDir      types.String `tfsdk:"vault_ca_cert_dir"`
+	VaultClientCertFile types.String `tfsdk:"vault_client_cert_file"`
+	VaultClientKeyFile  types.String `tfsdk:"vault_client_key_file"`
+	VaultTLSServerName  types.String `tfsdk:"vault_tls_server_name"`
+	VaultSkipTLSVerify  types.Bool   `tfsdk:"vault_skip_tls_verify"`
+
+	SkipCredentialsValidation types.Bool          `tfsdk:"skip_credentials_validation"`
+	ChildToken                *providerChildToken `tfsdk:"child_token"`
+
+	AWS *providerAWS `tfsdk:"aws"`
+
+	AuthLoginAppRole    *providerAuthLoginAppRole    `tfsdk:"auth_login_approle"`
+	AuthLoginJWT        *providerAuthLoginJWT        `tfsdk:"auth_login_jwt"`
+	AuthLoginKubernetes *providerAuthLoginKubernetes `tfsdk:"auth_login_kubernetes"`
+	AuthLoginAWS        *providerAuthLoginAWS        `tfsdk:"auth_login_aws"`
 }
 
 func (p *provider) Configure(ctx context.Context, req tfsdk.ConfigureProviderRequest, resp *tfsdk.ConfigureProviderResponse) {
@@ -52,18 +327,32 @@ func (p *provider) Configure(ctx context.Context, req tfsdk.ConfigureProviderReq
 		return
 	}
 
+	// The AWS configuration can't be loaded with values that depend on resources that were not created yet
+	for _, path := range config.AWS.unknownAttributes() {
+		resp.Diagnostics.AddAttributeError(
+			path,
+			"Unknown AWS configuration value",
+			"The value is not known yet, but the AWS configuration has to be loaded when the provider is configured. "+
+				"Use a value that doesn't depend on resources that are created in the same run.",
+		)
+	}
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	// Load AWS Configuration
-	// ... as we only access global AWS services (IAM, STS), we don't care about the region
-	cfg, err := awsConfig.LoadDefaultConfig(ctx)
+	// ... as we only access global AWS services (IAM, STS), the region is only used to determine the partition
+	cfg, err := loadAWSConfig(ctx, config.AWS)
 	if err != nil {
-		resp.Diagnostics.AddError(
+		resp.Diagnostics.AddAttributeError(
+			tftypes.NewAttributePath().WithAttributeName("aws"),
 			"Unable to create AWS configuration",
 			fmt.Sprintf("Received an error while loading the AWS configuration: %v", err),
 		)
 		return
 	}
-	p.iam = iam.NewFromConfig(cfg)
-	p.sts = sts.NewFromConfig(cfg)
+	p.iam = newIAMClient(cfg, config.AWS)
+	p.sts = newSTSClient(cfg, config.AWS)
 
 	// Load Vault Configuration
 	vaultConfig := vault.DefaultConfig()
@@ -71,6 +360,15 @@ func (p *provider) Configure(ctx context.Context, req tfsdk.ConfigureProviderReq
 		vaultConfig.Address = config.VaultAddress.Value
 	}
 
+	err = configureVaultTLS(vaultConfig, config)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Unable to configure TLS for the Vault client",
+			err.Error(),
+		)
+		return
+	}
+
 	p.vault, err = vault.NewClient(vaultConfig)
 	if err != nil {
 		resp.Diagnostics.AddError(
@@ -83,6 +381,180 @@ func (p *provider) Configure(ctx context.Context, req tfsdk.ConfigureProviderReq
 	if !config.VaultNamespace.Null {
 		p.vault.SetNamespace(config.VaultNamespace.Value)
 	}
+
+	// Login to Vault if an auth method was configured - otherwise the token is taken from VAULT_TOKEN
+	authLogins := config.authLogins()
+	if len(authLogins) > 1 {
+		resp.Diagnostics.AddError(
+			"Conflicting Vault auth methods",
+			"Only a single auth_login_* attribute can be configured at a time.",
+		)
+		return
+	}
+	for attributeName, authLogin := range authLogins {
+		err = authLogin.login(ctx, p.vault, cfg, config.AWS)
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(
+				tftypes.NewAttributePath().WithAttributeName(attributeName),
+				fmt.Sprintf("Unable to login to Vault using %s", authLogin.name()),
+				fmt.Sprintf("Received an error while logging in with the %s auth method: %v", authLogin.name(), err),
+			)
+			return
+		}
+	}
+
+	if config.ChildToken != nil {
+		err = p.createChildToken(ctx, config.ChildToken)
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(
+				tftypes.NewAttributePath().WithAttributeName("child_token"),
+				"Unable to create Vault child token",
+				fmt.Sprintf("Received an error while creating the child token: %v", err),
+			)
+			return
+		}
+	}
+
+	// Fail early if the credentials are not working - and not halfway through creating an access key
+	if !config.SkipCredentialsValidation.Value {
+		resp.Diagnostics.Append(p.validateCredentials(ctx)...)
+	}
+}
+
+// configureVaultTLS applies the TLS settings of the provider on top of the ones read from the VAULT_* environment
+// variables. Attributes that are not set keep the values of the environment.
+func configureVaultTLS(vaultConfig *vault.Config, config providerData) error {
+	err := vaultConfig.ConfigureTLS(&vault.TLSConfig{
+		CACert:        config.VaultCACertFile.Value,
+		CAPath:        config.VaultCACertDir.Value,
+		ClientCert:    config.VaultClientCertFile.Value,
+		ClientKey:     config.VaultClientKeyFile.Value,
+		TLSServerName: config.VaultTLSServerName.Value,
+	})
+	if err != nil {
+		return err
+	}
+
+	// ConfigureTLS can only enable skipping the verification, so an explicit false has to be set directly
+	if !config.VaultSkipTLSVerify.Null {
+		vaultConfig.HttpClient.Transport.(*http.Transport).TLSClientConfig.InsecureSkipVerify = config.VaultSkipTLSVerify.Value
+	}
+
+	return nil
+}
+
+// validateCredentials checks that the AWS and Vault credentials of the provider are valid, and logs the
+// identities they resolve to
+func (p *provider) validateCredentials(ctx context.Context) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	identity, err := p.sts.GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+	if err != nil {
+		diags.AddError(
+			"Invalid AWS credentials",
+			fmt.Sprintf("Received an error while validating the AWS credentials with sts:GetCallerIdentity: %v", err),
+		)
+	} else {
+		tflog.Info(ctx, "Validated AWS credentials", map[string]interface{}{
+			"account": aws.ToString(identity.Account),
+			"arn":     aws.ToString(identity.Arn),
+		})
+	}
+
+	if p.vault.Token() == "" {
+		diags.AddError(
+			"Missing Vault token",
+			"No Vault token is available. Set the VAULT_TOKEN environment variable or configure an auth_login_* attribute.",
+		)
+		return diags
+	}
+	token, err := p.vault.Auth().Token().LookupSelf()
+	if err != nil {
+		diags.AddError(
+			"Invalid Vault token",
+			fmt.Sprintf("Received an error while validating the Vault token with auth/token/lookup-self: %v", err),
+		)
+	} else {
+		policies, _ := token.TokenPolicies()
+		ttl, _ := token.TokenTTL()
+		tflog.Info(ctx, "Validated Vault token", map[string]interface{}{
+			"policies": policies,
+			"ttl":      ttl.String(),
+		})
+	}
+
+	return diags
+}
+
+// providerChildToken - configuration of the child_token provider attribute
+type providerChildToken struct {
+	TTL      types.String `tfsdk:"ttl"`
+	Policies []string     `tfsdk:"policies"`
+}
+
+// createChildToken creates a child token of the current Vault token and configures the client to use it.
+//
+// Terraform usually stops the provider process without letting it shut down cleanly, so the revocation of the
+// child token (see Close) is only best effort. The child token can't outlive its TTL though, as it's created
+// with an explicit max TTL and can't be renewed.
+func (p *provider) createChildToken(ctx context.Context, c *providerChildToken) error {
+	// If the provider is configured more than once, we don't want to leak the previous child token
+	p.revokeChildToken(ctx)
+
+	ttl := stringValueOrDefault(c.TTL, "20m")
+	renewable := false
+	secret, err := p.vault.Auth().Token().Create(&vault.TokenCreateRequest{
+		Policies:       c.Policies,
+		TTL:            ttl,
+		ExplicitMaxTTL: ttl,
+		Renewable:      &renewable,
+		DisplayName:    "terraform-provider-vaultsecure",
+	})
+	if err != nil {
+		return err
+	}
+	if secret == nil || secret.Auth == nil || secret.Auth.ClientToken == "" {
+		return fmt.Errorf("the response of auth/token/create did not contain a client token")
+	}
+
+	p.vault.SetToken(secret.Auth.ClientToken)
+	p.childToken = secret.Auth.ClientToken
+	tflog.Info(ctx, "Created Vault child token", map[string]interface{}{
+		"accessor": secret.Auth.Accessor,
+		"policies": secret.Auth.Policies,
+	})
+
+	return nil
+}
+
+func (p *provider) revokeChildToken(ctx context.Context) {
+	if p.childToken == "" {
+		return
+	}
+
+	// The client might already use a different token (i.e. if the provider is configured more than once)
+	client, err := p.vault.CloneWithHeaders()
+	if err == nil {
+		client.SetToken(p.childToken)
+		err = client.Auth().Token().RevokeSelf("")
+	}
+	if err != nil {
+		tflog.Warn(ctx, "Failed to revoke the Vault child token, it will expire after its TTL", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	p.childToken = ""
+	tflog.Info(ctx, "Revoked Vault child token")
+}
+
+// Close revokes the Vault child token if one was created by the given provider.
+// It is meant to be called after the provider server stopped serving, which Terraform doesn't always wait for.
+func Close(ctx context.Context, p tfsdk.Provider) {
+	if p, ok := p.(*provider); ok {
+		p.revokeChildToken(ctx)
+	}
 }
 
 // GetResources - Defines provider resources
@@ -0,0 +1,261 @@
+package vaultsecure
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	vault "github.com/hashicorp/vault/api"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const defaultKubernetesServiceAccountTokenFile = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+
+// providerAuthLogin is implemented by the configuration structs of all auth_login_* provider attributes
+type providerAuthLogin interface {
+	// name returns the name of the auth method, as used in diagnostics
+	name() string
+	// login logs in to Vault. The AWS configuration and the aws provider attribute are only used by the AWS
+	// auth method.
+	login(ctx context.Context, client *vault.Client, awsCfg aws.Config, awsAttr *providerAWS) error
+}
+
+// authLogins returns all auth_login_* attributes that are set in the provider configuration, keyed by attribute name
+func (d providerData) authLogins() map[string]providerAuthLogin {
+	authLogins := map[string]providerAuthLogin{}
+	if d.AuthLoginAppRole != nil {
+		authLogins["auth_login_approle"] = d.AuthLoginAppRole
+	}
+	if d.AuthLoginJWT != nil {
+		authLogins["auth_login_jwt"] = d.AuthLoginJWT
+	}
+	if d.AuthLoginKubernetes != nil {
+		authLogins["auth_login_kubernetes"] = d.AuthLoginKubernetes
+	}
+	if d.AuthLoginAWS != nil {
+		authLogins["auth_login_aws"] = d.AuthLoginAWS
+	}
+
+	return authLogins
+}
+
+// providerAuthLoginAppRole - configuration of the auth_login_approle provider attribute
+type providerAuthLoginAppRole struct {
+	Mount    types.String `tfsdk:"mount"`
+	RoleID   types.String `tfsdk:"role_id"`
+	SecretID types.String `tfsdk:"secret_id"`
+}
+
+func (a providerAuthLoginAppRole) name() string {
+	return "AppRole"
+}
+
+func (a providerAuthLoginAppRole) login(_ context.Context, client *vault.Client, _ aws.Config, _ *providerAWS) error {
+	data := map[string]interface{}{
+		"role_id": a.RoleID.Value,
+	}
+	if !a.SecretID.Null {
+		data["secret_id"] = a.SecretID.Value
+	}
+
+	return vaultLogin(client, stringValueOrDefault(a.Mount, "approle"), data)
+}
+
+// providerAuthLoginJWT - configuration of the auth_login_jwt provider attribute
+type providerAuthLoginJWT struct {
+	Mount   types.String `tfsdk:"mount"`
+	Role    types.String `tfsdk:"role"`
+	JWT     types.String `tfsdk:"jwt"`
+	JWTFile types.String `tfsdk:"jwt_file"`
+}
+
+func (a providerAuthLoginJWT) name() string {
+	return "JWT"
+}
+
+func (a providerAuthLoginJWT) login(_ context.Context, client *vault.Client, _ aws.Config, _ *providerAWS) error {
+	if a.JWT.Null == a.JWTFile.Null {
+		return errors.New("exactly one of jwt and jwt_file must be set")
+	}
+
+	jwt := a.JWT.Value
+	if !a.JWTFile.Null {
+		var err error
+		jwt, err = readTokenFile(a.JWTFile.Value)
+		if err != nil {
+			return err
+		}
+	}
+
+	return vaultLogin(client, stringValueOrDefault(a.Mount, "jwt"), map[string]interface{}{
+		"role": a.Role.Value,
+		"jwt":  jwt,
+	})
+}
+
+// providerAuthLoginKubernetes - configuration of the auth_login_kubernetes provider attribute
+type providerAuthLoginKubernetes struct {
+	Mount   types.String `tfsdk:"mount"`
+	Role    types.String `tfsdk:"role"`
+	JWTFile types.String `tfsdk:"jwt_file"`
+}
+
+func (a providerAuthLoginKubernetes) name() string {
+	return "Kubernetes"
+}
+
+func (a providerAuthLoginKubernetes) login(_ context.Context, client *vault.Client, _ aws.Config, _ *providerAWS) error {
+	jwt, err := readTokenFile(stringValueOrDefault(a.JWTFile, defaultKubernetesServiceAccountTokenFile))
+	if err != nil {
+		return err
+	}
+
+	return vaultLogin(client, stringValueOrDefault(a.Mount, "kubernetes"), map[string]interface{}{
+		"role": a.Role.Value,
+		"jwt":  jwt,
+	})
+}
+
+// providerAuthLoginAWS - configuration of the auth_login_aws provider attribute
+type providerAuthLoginAWS struct {
+	Mount       types.String `tfsdk:"mount"`
+	Role        types.String `tfsdk:"role"`
+	HeaderValue types.String `tfsdk:"header_value"`
+	STSRegion   types.String `tfsdk:"sts_region"`
+}
+
+func (a providerAuthLoginAWS) name() string {
+	return "AWS IAM"
+}
+
+// login signs a sts:GetCallerIdentity request with the AWS credentials of the provider and passes
+// it to Vault, which executes the request to verify the identity of the caller
+func (a providerAuthLoginAWS) login(ctx context.Context, client *vault.Client, awsCfg aws.Config, awsAttr *providerAWS) error {
+	if awsCfg.Credentials == nil {
+		return errors.New("no AWS credentials are available")
+	}
+	credentials, err := awsCfg.Credentials.Retrieve(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve the AWS credentials: %w", err)
+	}
+
+	stsEndpoint, stsRegion, err := a.stsEndpoint(awsCfg.Region, awsAttr)
+	if err != nil {
+		return err
+	}
+
+	body := "Action=GetCallerIdentity&Version=2011-06-15"
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, stsEndpoint, strings.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+	if !a.HeaderValue.Null {
+		request.Header.Set("X-Vault-AWS-IAM-Server-ID", a.HeaderValue.Value)
+	}
+
+	payloadHash := sha256.Sum256([]byte(body))
+	err = v4.NewSigner().SignHTTP(ctx, credentials, request, hex.EncodeToString(payloadHash[:]), "sts", stsRegion, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to sign the sts:GetCallerIdentity request: %w", err)
+	}
+
+	headers, err := json.Marshal(request.Header)
+	if err != nil {
+		return err
+	}
+
+	data := map[string]interface{}{
+		"iam_http_request_method": http.MethodPost,
+		"iam_request_url":         base64.StdEncoding.EncodeToString([]byte(stsEndpoint)),
+		"iam_request_body":        base64.StdEncoding.EncodeToString([]byte(body)),
+		"iam_request_headers":     base64.StdEncoding.EncodeToString(headers),
+	}
+	if !a.Role.Null {
+		data["role"] = a.Role.Value
+	}
+
+	return vaultLogin(client, stringValueOrDefault(a.Mount, "aws"), data)
+}
+
+// stsEndpoint returns the STS endpoint the login request is sent to, and the region it is signed for. By default,
+// Vault expects the request to be sent to the global STS endpoint. Outside of the aws partition (i.e. in GovCloud or
+// China), there is no global endpoint, so the regional endpoint of the configured AWS region is used instead. A custom
+// sts_endpoint of the aws provider attribute is always used as is.
+func (a providerAuthLoginAWS) stsEndpoint(awsRegion string, awsAttr *providerAWS) (string, string, error) {
+	region := defaultAWSRegion
+	regional := false
+	if !a.STSRegion.Null && a.STSRegion.Value != "" {
+		region = a.STSRegion.Value
+		regional = true
+	} else if awsRegion != "" {
+		endpoint, err := sts.NewDefaultEndpointResolver().ResolveEndpoint(awsRegion, sts.EndpointResolverOptions{})
+		if err != nil {
+			return "", "", fmt.Errorf("failed to resolve the STS endpoint of region %s: %w", awsRegion, err)
+		}
+		if endpoint.PartitionID != "aws" {
+			region = awsRegion
+			regional = true
+		}
+	}
+
+	if awsAttr != nil && !awsAttr.STSEndpoint.Null {
+		return strings.TrimSuffix(awsAttr.STSEndpoint.Value, "/") + "/", region, nil
+	}
+	if !regional {
+		return "https://sts.amazonaws.com/", region, nil
+	}
+
+	endpoint, err := sts.NewDefaultEndpointResolver().ResolveEndpoint(region, sts.EndpointResolverOptions{})
+	if err != nil {
+		return "", "", fmt.Errorf("failed to resolve the STS endpoint of region %s: %w", region, err)
+	}
+
+	return endpoint.URL + "/", region, nil
+}
+
+// vaultLogin performs a login against the auth method mounted at the given path and
+// configures the client to use the returned token for all further requests
+func vaultLogin(client *vault.Client, mount string, data map[string]interface{}) error {
+	// don't send along a token (i.e. from VAULT_TOKEN) with the login request
+	client.ClearToken()
+
+	secret, err := client.Logical().Write(fmt.Sprintf("auth/%s/login", mount), data)
+	if err != nil {
+		return err
+	}
+	if secret == nil || secret.Auth == nil || secret.Auth.ClientToken == "" {
+		return fmt.Errorf("the response of auth/%s/login did not contain a client token", mount)
+	}
+
+	client.SetToken(secret.Auth.ClientToken)
+	return nil
+}
+
+// readTokenFile reads a token from the given file, ignoring any surrounding whitespace
+func readTokenFile(path string) (string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read the token from file: %w", err)
+	}
+
+	return strings.TrimSpace(string(content)), nil
+}
+
+func stringValueOrDefault(value types.String, defaultValue string) string {
+	if value.Null || value.Unknown || value.Value == "" {
+		return defaultValue
+	}
+
+	return value.Value
+}
@@ -0,0 +1,40 @@
+package vaultsecure
+
+import (
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"testing"
+)
+
+func TestProviderAuthLoginAWSSTSEndpoint(t *testing.T) {
+	customEndpoint := &providerAWS{STSEndpoint: types.String{Value: "https://sts.example.com"}}
+
+	testCases := []struct {
+		name             string
+		stsRegion        types.String
+		awsRegion        string
+		awsAttr          *providerAWS
+		expectedEndpoint string
+		expectedRegion   string
+	}{
+		{"global endpoint", types.String{Null: true}, "eu-central-1", nil, "https://sts.amazonaws.com/", "us-east-1"},
+		{"sts_region", types.String{Value: "eu-west-1"}, "eu-central-1", nil, "https://sts.eu-west-1.amazonaws.com/", "eu-west-1"},
+		{"GovCloud", types.String{Null: true}, "us-gov-west-1", nil, "https://sts.us-gov-west-1.amazonaws.com/", "us-gov-west-1"},
+		{"China", types.String{Null: true}, "cn-north-1", nil, "https://sts.cn-north-1.amazonaws.com.cn/", "cn-north-1"},
+		{"custom endpoint", types.String{Null: true}, "eu-central-1", customEndpoint, "https://sts.example.com/", "us-east-1"},
+		{"custom endpoint in GovCloud", types.String{Null: true}, "us-gov-east-1", customEndpoint, "https://sts.example.com/", "us-gov-east-1"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			a := providerAuthLoginAWS{STSRegion: testCase.stsRegion}
+			endpoint, region, err := a.stsEndpoint(testCase.awsRegion, testCase.awsAttr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if endpoint != testCase.expectedEndpoint || region != testCase.expectedRegion {
+				t.Errorf("expected %s (signed for %s), got %s (signed for %s)",
+					testCase.expectedEndpoint, testCase.expectedRegion, endpoint, region)
+			}
+		})
+	}
+}
@@ -0,0 +1,240 @@
+package vaultsecure
+
+import (
+	"context"
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsConfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	stsTypes "github.com/aws/aws-sdk-go-v2/service/sts/types"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"sort"
+	"time"
+)
+
+// providerAWS - configuration of the aws provider attribute
+type providerAWS struct {
+	SharedConfigFiles      types.List   `tfsdk:"shared_config_files"`
+	SharedCredentialsFiles types.List   `tfsdk:"shared_credentials_files"`
+	Profile                types.String `tfsdk:"profile"`
+
+	Region      types.String `tfsdk:"region"`
+	IAMEndpoint types.String `tfsdk:"iam_endpoint"`
+	STSEndpoint types.String `tfsdk:"sts_endpoint"`
+
+	AssumeRole                *providerAWSAssumeRole                `tfsdk:"assume_role"`
+	AssumeRoleWithWebIdentity *providerAWSAssumeRoleWithWebIdentity `tfsdk:"assume_role_with_web_identity"`
+}
+
+type providerAWSAssumeRole struct {
+	RoleARN     types.String `tfsdk:"role_arn"`
+	SessionName types.String `tfsdk:"session_name"`
+	ExternalID  types.String `tfsdk:"external_id"`
+	SessionTags types.Map    `tfsdk:"session_tags"`
+	Duration    types.String `tfsdk:"duration"`
+}
+
+type providerAWSAssumeRoleWithWebIdentity struct {
+	RoleARN              types.String `tfsdk:"role_arn"`
+	SessionName          types.String `tfsdk:"session_name"`
+	WebIdentityToken     types.String `tfsdk:"web_identity_token"`
+	WebIdentityTokenFile types.String `tfsdk:"web_identity_token_file"`
+}
+
+// webIdentityToken implements stscreds.IdentityTokenRetriever for a token that is passed in directly
+type webIdentityToken string
+
+func (t webIdentityToken) GetIdentityToken() ([]byte, error) {
+	return []byte(t), nil
+}
+
+// defaultAWSRegion is used if no region is configured. IAM and STS are global services, and their
+// global endpoints in the aws partition are located in us-east-1
+const defaultAWSRegion = "us-east-1"
+
+// loadAWSConfig loads the AWS configuration from the default sources (environment variables, shared
+// configuration files, ...) and applies the settings of the aws provider attribute on top of it
+func loadAWSConfig(ctx context.Context, c *providerAWS) (aws.Config, error) {
+	optFns := []func(*awsConfig.LoadOptions) error{
+		awsConfig.WithDefaultRegion(defaultAWSRegion),
+	}
+	if c == nil {
+		return awsConfig.LoadDefaultConfig(ctx, optFns...)
+	}
+
+	if !c.Region.Null {
+		optFns = append(optFns, awsConfig.WithRegion(c.Region.Value))
+	}
+	if files := listStrings(c.SharedConfigFiles); len(files) > 0 {
+		optFns = append(optFns, awsConfig.WithSharedConfigFiles(files))
+	}
+	if files := listStrings(c.SharedCredentialsFiles); len(files) > 0 {
+		optFns = append(optFns, awsConfig.WithSharedCredentialsFiles(files))
+	}
+	if !c.Profile.Null {
+		optFns = append(optFns, awsConfig.WithSharedConfigProfile(c.Profile.Value))
+	}
+
+	cfg, err := awsConfig.LoadDefaultConfig(ctx, optFns...)
+	if err != nil {
+		return cfg, err
+	}
+
+	// The web identity role is assumed first, so its credentials can be used to assume the role in assume_role
+	if c.AssumeRoleWithWebIdentity != nil {
+		a := c.AssumeRoleWithWebIdentity
+		if a.WebIdentityToken.Null == a.WebIdentityTokenFile.Null {
+			return cfg, fmt.Errorf("exactly one of web_identity_token and web_identity_token_file must be set in assume_role_with_web_identity")
+		}
+
+		var tokenRetriever stscreds.IdentityTokenRetriever = webIdentityToken(a.WebIdentityToken.Value)
+		if !a.WebIdentityTokenFile.Null {
+			tokenRetriever = stscreds.IdentityTokenFile(a.WebIdentityTokenFile.Value)
+		}
+
+		provider := stscreds.NewWebIdentityRoleProvider(newSTSClient(cfg, c), a.RoleARN.Value, tokenRetriever,
+			func(o *stscreds.WebIdentityRoleOptions) {
+				o.RoleSessionName = stringValueOrDefault(a.SessionName, o.RoleSessionName)
+			})
+		cfg.Credentials = aws.NewCredentialsCache(provider)
+	}
+
+	if c.AssumeRole != nil {
+		a := c.AssumeRole
+
+		var duration time.Duration
+		if !a.Duration.Null {
+			duration, err = time.ParseDuration(a.Duration.Value)
+			if err != nil {
+				return cfg, fmt.Errorf("invalid duration in assume_role: %w", err)
+			}
+		}
+
+		provider := stscreds.NewAssumeRoleProvider(newSTSClient(cfg, c), a.RoleARN.Value,
+			func(o *stscreds.AssumeRoleOptions) {
+				o.RoleSessionName = stringValueOrDefault(a.SessionName, o.RoleSessionName)
+				if !a.ExternalID.Null {
+					o.ExternalID = aws.String(a.ExternalID.Value)
+				}
+				for key, value := range a.SessionTags.Elems {
+					if value, ok := value.(types.String); ok && !value.Null {
+						o.Tags = append(o.Tags, stsTypes.Tag{Key: aws.String(key), Value: aws.String(value.Value)})
+					}
+				}
+				if duration != 0 {
+					o.Duration = duration
+				}
+			})
+		cfg.Credentials = aws.NewCredentialsCache(provider)
+	}
+
+	return cfg, nil
+}
+
+// unknownAttributes returns the paths of the attributes that are not known yet (i.e. as they depend on a resource
+// that was not created yet). The AWS configuration is loaded when the provider is configured, so all of them have to be
+// known at that time.
+func (c *providerAWS) unknownAttributes() []*tftypes.AttributePath {
+	if c == nil {
+		return nil
+	}
+
+	path := tftypes.NewAttributePath().WithAttributeName("aws")
+	values := map[*tftypes.AttributePath]attr.Value{
+		path.WithAttributeName("shared_config_files"):      c.SharedConfigFiles,
+		path.WithAttributeName("shared_credentials_files"): c.SharedCredentialsFiles,
+		path.WithAttributeName("profile"):                  c.Profile,
+		path.WithAttributeName("region"):                   c.Region,
+		path.WithAttributeName("iam_endpoint"):             c.IAMEndpoint,
+		path.WithAttributeName("sts_endpoint"):             c.STSEndpoint,
+	}
+	if a := c.AssumeRole; a != nil {
+		assumeRolePath := path.WithAttributeName("assume_role")
+		values[assumeRolePath.WithAttributeName("role_arn")] = a.RoleARN
+		values[assumeRolePath.WithAttributeName("session_name")] = a.SessionName
+		values[assumeRolePath.WithAttributeName("external_id")] = a.ExternalID
+		values[assumeRolePath.WithAttributeName("session_tags")] = a.SessionTags
+		values[assumeRolePath.WithAttributeName("duration")] = a.Duration
+	}
+	if a := c.AssumeRoleWithWebIdentity; a != nil {
+		webIdentityPath := path.WithAttributeName("assume_role_with_web_identity")
+		values[webIdentityPath.WithAttributeName("role_arn")] = a.RoleARN
+		values[webIdentityPath.WithAttributeName("session_name")] = a.SessionName
+		values[webIdentityPath.WithAttributeName("web_identity_token")] = a.WebIdentityToken
+		values[webIdentityPath.WithAttributeName("web_identity_token_file")] = a.WebIdentityTokenFile
+	}
+
+	var unknown []*tftypes.AttributePath
+	for attributePath, value := range values {
+		if isUnknown(value) {
+			unknown = append(unknown, attributePath)
+		}
+	}
+	sort.Slice(unknown, func(i, j int) bool {
+		return unknown[i].String() < unknown[j].String()
+	})
+
+	return unknown
+}
+
+// isUnknown returns true if the value, or one of its elements, is unknown
+func isUnknown(value attr.Value) bool {
+	switch value := value.(type) {
+	case types.String:
+		return value.Unknown
+	case types.List:
+		if value.Unknown {
+			return true
+		}
+		for _, elem := range value.Elems {
+			if isUnknown(elem) {
+				return true
+			}
+		}
+	case types.Map:
+		if value.Unknown {
+			return true
+		}
+		for _, elem := range value.Elems {
+			if isUnknown(elem) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// listStrings returns the known and non-null elements of a list of strings
+func listStrings(list types.List) []string {
+	var values []string
+	for _, elem := range list.Elems {
+		if value, ok := elem.(types.String); ok && !value.Null && !value.Unknown {
+			values = append(values, value.Value)
+		}
+	}
+
+	return values
+}
+
+// newIAMClient creates an IAM client, using the custom IAM endpoint of the aws provider attribute if set
+func newIAMClient(cfg aws.Config, c *providerAWS) *iam.Client {
+	return iam.NewFromConfig(cfg, func(o *iam.Options) {
+		if c != nil && !c.IAMEndpoint.Null {
+			o.EndpointResolver = iam.EndpointResolverFromURL(c.IAMEndpoint.Value)
+		}
+	})
+}
+
+// newSTSClient creates an STS client, using the custom STS endpoint of the aws provider attribute if set
+func newSTSClient(cfg aws.Config, c *providerAWS) *sts.Client {
+	return sts.NewFromConfig(cfg, func(o *sts.Options) {
+		if c != nil && !c.STSEndpoint.Null {
+			o.EndpointResolver = sts.EndpointResolverFromURL(c.STSEndpoint.Value)
+		}
+	})
+}
@@ -2,22 +2,43 @@ package vaultsecure
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
-	"github.com/avast/retry-go/v4"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/iam"
+	iamTypes "github.com/aws/aws-sdk-go-v2/service/iam/types"
 	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-go/tftypes"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
+	vault "github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/sdk/helper/consts"
+	"net/http"
+	"path"
+	"sort"
 	"strings"
 	"time"
 )
 
 var ErrAccessKeyNotFound = errors.New("AWS access key with the given AwsAccessKeyID was not found within the given user")
 
+// maxAccessKeysPerUser is the maximum number of access keys (active or inactive) of an IAM user
+const maxAccessKeysPerUser = 2
+
+const (
+	existingKeysFail       = "fail"
+	existingKeysDelete     = "delete"
+	existingKeysDeactivate = "deactivate"
+)
+
+const (
+	onDestroyDelete     = "delete"
+	onDestroyDeactivate = "deactivate"
+	onDestroyRetain     = "retain"
+)
+
 type resourceAwsSecretAccessKeyType struct{}
 
 func (r resourceAwsSecretAccessKeyType) GetSchema(_ context.Context) (tfsdk.Schema, diag.Diagnostics) {
@@ -26,38 +47,208 @@ func (r resourceAwsSecretAccessKeyType) GetSchema(_ context.Context) (tfsdk.Sche
 			"id": {
 				Type:     types.StringType,
 				Computed: true,
+				PlanModifiers: []tfsdk.AttributePlanModifier{
+					tfsdk.UseStateForUnknown(),
+				},
 			},
 
 			"aws_iam_username": {
 				Type:        types.StringType,
 				Required:    true,
 				Description: "Username of the AWS IAM user.",
-				PlanModifiers: []tfsdk.AttributePlanModifier{
-					tfsdk.RequiresReplace(),
-				},
 			},
 			"aws_access_key_id": {
 				Type:     types.StringType,
 				Computed: true,
+				PlanModifiers: []tfsdk.AttributePlanModifier{
+					tfsdk.UseStateForUnknown(),
+				},
 			},
 			"aws_access_key_creation_date": {
 				Type:        types.StringType,
 				Computed:    true,
 				Description: "Contains the date (in RFC3339 format) when the access key was created",
+				PlanModifiers: []tfsdk.AttributePlanModifier{
+					tfsdk.UseStateForUnknown(),
+				},
+			},
+			"status": {
+				Type:        types.StringType,
+				Computed:    true,
+				Description: "Status of the access key (`Active` or `Inactive`).",
+				PlanModifiers: []tfsdk.AttributePlanModifier{
+					tfsdk.UseStateForUnknown(),
+				},
+			},
+			"last_used_date": {
+				Type:        types.StringType,
+				Computed:    true,
+				Description: "Date (in RFC3339 format) when the access key was last used. Null if it was never used.",
+				PlanModifiers: []tfsdk.AttributePlanModifier{
+					tfsdk.UseStateForUnknown(),
+				},
+			},
+			"last_used_service": {
+				Type:        types.StringType,
+				Computed:    true,
+				Description: "Name of the AWS service the access key was last used with. Null if it was never used.",
+				PlanModifiers: []tfsdk.AttributePlanModifier{
+					tfsdk.UseStateForUnknown(),
+				},
+			},
+			"last_used_region": {
+				Type:        types.StringType,
+				Computed:    true,
+				Description: "AWS region where the access key was last used. Null if it was never used.",
+				PlanModifiers: []tfsdk.AttributePlanModifier{
+					tfsdk.UseStateForUnknown(),
+				},
+			},
+			"adopted": {
+				Type:     types.BoolType,
+				Computed: true,
+				Description: "Whether the access key was rotated outside of Terraform, and this resource took ownership " +
+					"of it without rotating it.",
+				PlanModifiers: []tfsdk.AttributePlanModifier{
+					tfsdk.UseStateForUnknown(),
+				},
+			},
+
+			"rotation_interval": {
+				Type:     types.StringType,
+				Optional: true,
+				Description: "Maximum age of the access key (i.e. `2160h` for 90 days). If the access key is older, " +
+					"it is rotated in-place during the next apply.",
+				Validators: []tfsdk.AttributeValidator{
+					durationValidator{},
+				},
+			},
+			"rotation_triggers": {
+				Type:     types.MapType{ElemType: types.StringType},
+				Optional: true,
+				Description: "Arbitrary map of values that, when changed, will trigger an in-place rotation of the " +
+					"access key.",
 			},
 
+			"vault_namespace": {
+				Type:     types.StringType,
+				Optional: true,
+				Description: "Vault namespace of the AWS Secret engine, relative to the namespace " +
+					"configured in the provider.",
+			},
 			"vault_engine_path": {
 				Type:        types.StringType,
 				Required:    true,
 				Description: "Path to the AWS Secret engine in Vault.",
-				PlanModifiers: []tfsdk.AttributePlanModifier{
-					tfsdk.RequiresReplace(),
-				},
 			},
 			"vault_access_key_id": {
 				Type:     types.StringType,
 				Computed: true,
+				PlanModifiers: []tfsdk.AttributePlanModifier{
+					tfsdk.UseStateForUnknown(),
+				},
+			},
+
+			"region": {
+				Type:        types.StringType,
+				Optional:    true,
+				Description: "AWS region of the secret engine. Uses the default of Vault if not set.",
+			},
+			"iam_endpoint": {
+				Type:        types.StringType,
+				Optional:    true,
+				Description: "Custom IAM endpoint of the secret engine.",
+			},
+			"sts_endpoint": {
+				Type:        types.StringType,
+				Optional:    true,
+				Description: "Custom STS endpoint of the secret engine.",
+			},
+			"sts_region": {
+				Type:        types.StringType,
+				Optional:    true,
+				Description: "Region of the custom STS endpoint of the secret engine.",
+			},
+			"max_retries": {
+				Type:        types.Int64Type,
+				Optional:    true,
+				Description: "Number of retries of the secret engine for failed AWS requests. Uses the default of Vault if not set.",
+			},
+			"username_template": {
+				Type:        types.StringType,
+				Optional:    true,
+				Description: "Template of the usernames of the IAM users created by the secret engine.",
+			},
+
+			"rotation_period": {
+				Type:     types.Int64Type,
+				Optional: true,
+				Description: "Period (in seconds) after which Vault rotates the access key automatically. Conflicts " +
+					"with `rotation_schedule`. Requires Vault Enterprise 1.19 or newer.",
+			},
+			"rotation_schedule": {
+				Type:     types.StringType,
+				Optional: true,
+				Description: "Cron-style schedule on which Vault rotates the access key automatically. Conflicts " +
+					"with `rotation_period`. Requires Vault Enterprise 1.19 or newer.",
 			},
+			"rotation_window": {
+				Type:     types.Int64Type,
+				Optional: true,
+				Description: "Maximum duration (in seconds) that Vault is allowed to perform a scheduled rotation " +
+					"after the `rotation_schedule` was triggered.",
+			},
+			"disable_automated_rotation": {
+				Type:        types.BoolType,
+				Optional:    true,
+				Description: "Pauses the automated rotation of the access key by Vault.",
+			},
+
+			"existing_keys": {
+				Type:     types.StringType,
+				Optional: true,
+				Description: "What happens if the IAM user already has access keys: `fail` (default), `delete` " +
+					"(the existing access keys are deleted after the new access key was seeded into Vault) or " +
+					"`deactivate` (the existing access keys are deactivated instead, which prevents Vault from " +
+					"rotating the access key until they are deleted).",
+				Validators: []tfsdk.AttributeValidator{
+					stringOneOfValidator{values: []string{existingKeysFail, existingKeysDelete, existingKeysDeactivate}},
+				},
+			},
+			"auto_repair": {
+				Type:     types.BoolType,
+				Optional: true,
+				Description: "If the access key no longer exists in IAM, or the access key configured in the secret " +
+					"engine doesn't exist in IAM, a new access key is seeded in-place instead of removing the resource " +
+					"from the state.",
+			},
+			"rotate_on_adopt": {
+				Type:     types.BoolType,
+				Optional: true,
+				Description: "If the access key was rotated outside of Terraform, the next apply rotates it once more " +
+					"after taking ownership of it, as its secret key might have been handled outside of Vault. Conflicts " +
+					"with `rotation_period` and `rotation_schedule`.",
+			},
+
+			"on_destroy": {
+				Type:     types.StringType,
+				Optional: true,
+				Description: "What happens to the access key when the resource is destroyed: `delete` (default), " +
+					"`deactivate` (the access key is kept as inactive, i.e. for a grace period) or `retain`.",
+				Validators: []tfsdk.AttributeValidator{
+					stringOneOfValidator{values: []string{onDestroyDelete, onDestroyDeactivate, onDestroyRetain}},
+				},
+			},
+			"clear_root_credentials_on_destroy": {
+				Type:     types.BoolType,
+				Optional: true,
+				Description: "Clears the root credentials of the secret engine when the resource is destroyed, so " +
+					"it stops handing out credentials.",
+			},
+
+			"manage_iam_user": managedIAMUserAttribute(),
+
+			"timeouts": timeoutsAttribute(),
 		},
 	}, nil
 }
@@ -72,16 +263,54 @@ type resourceAwsSecretAccessKey struct {
 	p provider
 }
 
+func (r resourceAwsSecretAccessKey) ValidateConfig(ctx context.Context, req tfsdk.ValidateResourceConfigRequest, resp *tfsdk.ValidateResourceConfigResponse) {
+	var config AwsSecretAccessKey
+	diags := req.Config.Get(ctx, &config)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if !config.RotationPeriod.Null && !config.RotationSchedule.Null {
+		resp.Diagnostics.AddAttributeError(
+			tftypes.NewAttributePath().WithAttributeName("rotation_schedule"),
+			"Conflicting automated rotation settings",
+			"Only one of rotation_period and rotation_schedule can be set.",
+		)
+	}
+
+	// The access keys rotated by Vault can't be told apart from the ones rotated outside of Terraform (see
+	// refreshState), so each automated rotation would be followed by another rotation
+	if config.RotateOnAdopt.Value && (!config.RotationPeriod.Null || !config.RotationSchedule.Null) {
+		resp.Diagnostics.AddAttributeError(
+			tftypes.NewAttributePath().WithAttributeName("rotate_on_adopt"),
+			"Conflicting rotation settings",
+			"rotate_on_adopt can't be enabled together with the automated rotation of Vault (rotation_period or "+
+				"rotation_schedule), as every access key rotated by Vault would be rotated once more.",
+		)
+	}
+
+	// A managed IAM user can only be deleted without access keys
+	if config.ManageIAMUser != nil && (config.OnDestroy.Value == onDestroyDeactivate || config.OnDestroy.Value == onDestroyRetain) {
+		resp.Diagnostics.AddAttributeError(
+			tftypes.NewAttributePath().WithAttributeName("on_destroy"),
+			"Conflicting on_destroy setting",
+			fmt.Sprintf("The access key of a managed IAM user (see manage_iam_user) is always deleted, on_destroy can't be %q.", config.OnDestroy.Value),
+		)
+	}
+}
+
 // ModifyPlan checks if the access key that is used by the vault engine is identical to the one we track in AWS
 //
 // If not, we want to force a replacement of the resource, as we are not sure that the access key used by Vault
 // is working correctly (it might have an invalid secret key set).
+//
+// If the secret engine or the IAM user changed, or if the access key needs to be repaired (see auto_repair), a new
+// access key is seeded in-place. If the access key is older than the rotation interval, the rotation triggers
+// changed or an adopted access key has to be rotated (see rotate_on_adopt), it is rotated in-place (see Update).
+//
+// Before that, the IAM user and the secret engine are validated if they are created or changed (see validatePlan).
 func (r resourceAwsSecretAccessKey) ModifyPlan(ctx context.Context, req tfsdk.ModifyResourcePlanRequest, resp *tfsdk.ModifyResourcePlanResponse) {
-	if req.State.Raw.IsNull() {
-		// if we're creating the resource, no need to delete and recreate it
-		return
-	}
-
 	if req.Plan.Raw.IsNull() {
 		// if we're deleting the resource, no need to delete and recreate it
 		return
@@ -94,19 +323,79 @@ func (r resourceAwsSecretAccessKey) ModifyPlan(ctx context.Context, req tfsdk.Mo
 		return
 	}
 
-	if plan.AwsAccessKeyID.Equal(plan.VaultAccessKeyID) {
+	if req.State.Raw.IsNull() {
+		// if we're creating the resource, no need to delete and recreate it
+		r.validatePlan(ctx, plan, nil, &resp.Diagnostics)
+		return
+	}
+
+	var state AwsSecretAccessKey
+	diags = req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	r.validatePlan(ctx, plan, &state, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
 		return
 	}
-	tflog.Info(ctx, "The AWS access key that was managed by this resource is no longer the one that is configured in Vault")
 
-	// not sure if there is a more "type-safe" way to get the attribute path...
-	// i.e. in a way that would cause compile-time errors if the attribute is renamed
-	resp.RequiresReplace = append(resp.RequiresReplace,
-		tftypes.NewAttributePath().WithAttributeName("vault_access_key_id"))
+	switch {
+	case plan.ManageIAMUser != nil && state.ManageIAMUser != nil && !state.AwsIamUsername.Equal(plan.AwsIamUsername):
+		tflog.Info(ctx, "The managed IAM user will be replaced")
+
+		resp.RequiresReplace = append(resp.RequiresReplace,
+			tftypes.NewAttributePath().WithAttributeName("aws_iam_username"))
+	case state.AwsAccessKeyID.Null:
+		// The access key is missing, but the resource was kept in the state because of auto_repair (see Read)
+		resp.Diagnostics.AddWarning(
+			"Missing access key will be repaired",
+			fmt.Sprintf("The access key of the IAM user %s no longer exists. As auto_repair is enabled, a new access key "+
+				"will be created and configured in the secret engine %s in-place.", state.AwsIamUsername.Value, state.VaultEnginePath.Value),
+		)
+	case plan.AutoRepair.Value && !plan.AwsAccessKeyID.Equal(plan.VaultAccessKeyID) &&
+		r.isAccessKeyMissing(ctx, state.AwsIamUsername.Value, state.VaultAccessKeyID.Value):
+		resp.Diagnostics.AddWarning(
+			"Broken secret engine will be repaired",
+			fmt.Sprintf("The access key configured in the secret engine %s (ID: %s) doesn't exist in IAM. As auto_repair "+
+				"is enabled, a new access key will be created and configured in the secret engine in-place.",
+				state.VaultEnginePath.Value, state.VaultAccessKeyID.Value),
+		)
+	case !plan.AwsAccessKeyID.Equal(plan.VaultAccessKeyID):
+		tflog.Info(ctx, "The AWS access key that was managed by this resource is no longer the one that is configured in Vault")
+
+		// not sure if there is a more "type-safe" way to get the attribute path...
+		// i.e. in a way that would cause compile-time errors if the attribute is renamed
+		resp.RequiresReplace = append(resp.RequiresReplace,
+			tftypes.NewAttributePath().WithAttributeName("vault_access_key_id"))
+	case isRelocated(state, plan):
+		tflog.Info(ctx, "The access key will be moved to a different secret engine or IAM user")
+
+		if plan.VaultNamespace.Unknown || plan.VaultEnginePath.Unknown || plan.AwsIamUsername.Unknown {
+			plan.ID.Unknown = true
+		} else {
+			plan.ID = types.String{Value: awsSecretAccessKeyID(plan.VaultNamespace, plan.VaultEnginePath, plan.AwsIamUsername)}
+		}
+	case plan.RotateOnAdopt.Value && state.Adopted.Value:
+		tflog.Info(ctx, "The access key was rotated outside of Terraform and will be rotated once more")
+	case isRotationDue(state, plan):
+		tflog.Info(ctx, "The access key is older than the rotation interval and will be rotated")
+	case !state.RotationTriggers.Equal(plan.RotationTriggers):
+		tflog.Info(ctx, "The rotation triggers changed, the access key will be rotated")
+	default:
+		return
+	}
 
 	plan.AwsAccessKeyID.Unknown = true
 	plan.AwsAccessKeyCreationDate.Unknown = true
+	plan.Status.Unknown = true
+	plan.LastUsedDate.Unknown = true
+	plan.LastUsedService.Unknown = true
+	plan.LastUsedRegion.Unknown = true
 	plan.VaultAccessKeyID.Unknown = true
+	// The new access key is created by this resource (or by Vault on its behalf)
+	plan.Adopted = types.Bool{Value: false}
 
 	diags = resp.Plan.Set(ctx, &plan)
 	resp.Diagnostics.Append(diags...)
@@ -115,6 +404,97 @@ func (r resourceAwsSecretAccessKey) ModifyPlan(ctx context.Context, req tfsdk.Mo
 	}
 }
 
+// validatePlan checks that the IAM user exists (or doesn't exist yet, if it's created by this resource) and that
+// the secret engine is an AWS secret engine, so typos are reported during the plan instead of halfway through
+// applying. Only values that are known and created or changed are validated. If the provider is not allowed to
+// check a value, a warning is reported instead.
+func (r resourceAwsSecretAccessKey) validatePlan(ctx context.Context, plan AwsSecretAccessKey, state *AwsSecretAccessKey, diags *diag.Diagnostics) {
+	if r.p.iam == nil || r.p.vault == nil {
+		// The provider is not configured (i.e. because its configuration is not known yet)
+		return
+	}
+
+	// A managed IAM user must not exist yet, unless it's managed by this resource already
+	isManagedIAMUserCreated := plan.ManageIAMUser != nil && (state == nil || state.ManageIAMUser == nil)
+
+	if !plan.AwsIamUsername.Unknown && (state == nil || isManagedIAMUserCreated || !state.AwsIamUsername.Equal(plan.AwsIamUsername)) {
+		_, err := r.p.iam.GetUser(ctx, &iam.GetUserInput{UserName: aws.String(plan.AwsIamUsername.Value)})
+
+		var notFound *iamTypes.NoSuchEntityException
+		switch {
+		case plan.ManageIAMUser != nil && err == nil:
+			diags.AddAttributeError(
+				tftypes.NewAttributePath().WithAttributeName("aws_iam_username"),
+				"IAM user already exists",
+				fmt.Sprintf("The IAM user %s already exists, so it can't be created by this resource (see manage_iam_user).",
+					plan.AwsIamUsername.Value),
+			)
+		case plan.ManageIAMUser == nil && errors.As(err, &notFound):
+			diags.AddAttributeError(
+				tftypes.NewAttributePath().WithAttributeName("aws_iam_username"),
+				"IAM user not found",
+				fmt.Sprintf("The IAM user %s does not exist.", plan.AwsIamUsername.Value),
+			)
+		case err != nil && !errors.As(err, &notFound):
+			diags.AddAttributeWarning(
+				tftypes.NewAttributePath().WithAttributeName("aws_iam_username"),
+				"Could not validate the IAM user",
+				fmt.Sprintf("The IAM user %s could not be fetched: %v", plan.AwsIamUsername.Value, err),
+			)
+		}
+	}
+
+	if !plan.VaultEnginePath.Unknown && !plan.VaultNamespace.Unknown &&
+		(state == nil || !state.VaultEnginePath.Equal(plan.VaultEnginePath) || !isSameVaultNamespace(state.VaultNamespace, plan.VaultNamespace)) {
+		attributePath := tftypes.NewAttributePath().WithAttributeName("vault_engine_path")
+
+		vaultClient, err := r.vaultClient(plan.VaultNamespace)
+		if err != nil {
+			diags.AddError("Unable to create Vault client", err.Error())
+			return
+		}
+
+		mount, err := vaultClient.Logical().ReadWithContext(ctx, fmt.Sprintf("sys/mounts/%s", plan.VaultEnginePath.Value))
+
+		var responseErr *vault.ResponseError
+		switch {
+		case err == nil && mount == nil || isMissingMountError(err):
+			diags.AddAttributeError(
+				attributePath,
+				"Secret engine not found",
+				fmt.Sprintf("There is no secret engine mounted at %s.", plan.VaultEnginePath.Value),
+			)
+		case errors.As(err, &responseErr) && responseErr.StatusCode == http.StatusForbidden:
+			diags.AddAttributeWarning(
+				attributePath,
+				"Could not validate the secret engine",
+				fmt.Sprintf("The Vault token is not allowed to read the mount of the secret engine %s: %v", plan.VaultEnginePath.Value, err),
+			)
+		case errors.As(err, &responseErr):
+			// i.e. older Vault versions don't support reading a single mount (405)
+			diags.AddAttributeWarning(
+				attributePath,
+				"Could not validate the secret engine",
+				fmt.Sprintf("The mount of the secret engine %s could not be read (status %d): %v",
+					plan.VaultEnginePath.Value, responseErr.StatusCode, err),
+			)
+		case err != nil:
+			diags.AddAttributeWarning(
+				attributePath,
+				"Could not validate the secret engine",
+				fmt.Sprintf("The mount of the secret engine %s could not be read: %v", plan.VaultEnginePath.Value, err),
+			)
+		case mount.Data["type"] != "aws":
+			diags.AddAttributeError(
+				attributePath,
+				"Invalid secret engine",
+				fmt.Sprintf("The secret engine mounted at %s is of type %v, but an AWS secret engine is required.",
+					plan.VaultEnginePath.Value, mount.Data["type"]),
+			)
+		}
+	}
+}
+
 func (r resourceAwsSecretAccessKey) Create(ctx context.Context, req tfsdk.CreateResourceRequest, resp *tfsdk.CreateResourceResponse) {
 	var plan AwsSecretAccessKey
 	diags := req.Plan.Get(ctx, &plan)
@@ -123,38 +503,52 @@ func (r resourceAwsSecretAccessKey) Create(ctx context.Context, req tfsdk.Create
 		return
 	}
 
-	keys, err := r.p.iam.ListAccessKeys(ctx, &iam.ListAccessKeysInput{UserName: aws.String(plan.AwsIamUsername.Value), MaxItems: aws.Int32(1)})
+	ctx, cancel := context.WithTimeout(ctx, plan.Timeouts.create())
+	defer cancel()
+
+	vaultClient, err := r.vaultClient(plan.VaultNamespace)
 	if err != nil {
-		resp.Diagnostics.AddError(
-			"Failed to get existing access keys",
-			fmt.Sprintf("Failed to fetch the list of existing access keys for the given IAM user: %v", err),
-		)
+		resp.Diagnostics.AddError("Unable to create Vault client", err.Error())
 		return
 	}
-	if len(keys.AccessKeyMetadata) > 0 {
-		resp.Diagnostics.AddError(
-			"Existing access key detected",
-			"At least one existing access key was found on the specified IAM user. This is not allowed, as the access key "+
-				"will be created by this resources and rotated by Vault.",
-		)
+
+	if plan.ManageIAMUser != nil {
+		err = r.createManagedIAMUser(ctx, plan.AwsIamUsername.Value, plan.ManageIAMUser)
+		if err != nil {
+			resp.Diagnostics.AddError("Error creating the IAM user", err.Error())
+			return
+		}
+	}
+
+	existingKeys, diags := r.existingAccessKeys(ctx, plan)
+	if diags.HasError() {
+		// existingAccessKeys reports a single error, which the outcome of the rollback is appended to
+		rollback := r.rollbackManagedIAMUser(plan)
+		for _, d := range diags {
+			resp.Diagnostics.AddError(d.Summary(), d.Detail()+rollback)
+		}
 		return
 	}
+	resp.Diagnostics.Append(diags...)
 
 	key, err := r.p.iam.CreateAccessKey(ctx, &iam.CreateAccessKeyInput{UserName: aws.String(plan.AwsIamUsername.Value)})
 	if err != nil {
+		resp.Diagnostics.AddError("Error creating access key", err.Error()+r.rollbackManagedIAMUser(plan))
 		return
 	}
 
-	state := AwsSecretAccessKey{
-		ID: types.String{Value: fmt.Sprintf("%s:%s", plan.VaultEnginePath.Value, plan.AwsIamUsername.Value)},
-
-		AwsAccessKeyID:           types.String{Value: *key.AccessKey.AccessKeyId},
-		AwsIamUsername:           types.String{Value: *key.AccessKey.UserName},
-		AwsAccessKeyCreationDate: types.String{Value: key.AccessKey.CreateDate.Format(time.RFC3339)},
-
-		VaultEnginePath:  plan.VaultEnginePath,
-		VaultAccessKeyID: types.String{Null: true},
-	}
+	// The access key is tracked in the state right away, so it's not lost if the provider is interrupted. From now
+	// on, every failure until the access key is rotated by Vault is rolled back (see rollbackCreate).
+	state := plan
+	state.ID = types.String{Value: awsSecretAccessKeyID(plan.VaultNamespace, plan.VaultEnginePath, plan.AwsIamUsername)}
+	state.AwsAccessKeyID = types.String{Value: *key.AccessKey.AccessKeyId}
+	state.AwsAccessKeyCreationDate = types.String{Value: key.AccessKey.CreateDate.Format(time.RFC3339)}
+	state.Status = types.String{Value: string(key.AccessKey.Status)}
+	state.LastUsedDate = types.String{Null: true}
+	state.LastUsedService = types.String{Null: true}
+	state.LastUsedRegion = types.String{Null: true}
+	state.Adopted = types.Bool{Value: false}
+	state.VaultAccessKeyID = types.String{Null: true}
 	diags = resp.State.Set(ctx, state)
 	resp.Diagnostics.Append(diags...)
 	if resp.Diagnostics.HasError() {
@@ -166,53 +560,58 @@ func (r resourceAwsSecretAccessKey) Create(ctx context.Context, req tfsdk.Create
 	})
 
 	// Set Access Key in AWS Secret Engine
-	secretEngineData := map[string]interface{}{
-		"access_key": *key.AccessKey.AccessKeyId,
-		"secret_key": *key.AccessKey.SecretAccessKey,
+	err = writeRootCredentials(ctx, vaultClient, plan.VaultEnginePath.Value, *key.AccessKey.AccessKeyId, *key.AccessKey.SecretAccessKey, plan.rootConfig())
+	if err != nil {
+		r.rollbackCreate(ctx, vaultClient, state, false, "Error writing access key to the AWS backend", err, resp)
+		return
 	}
-	_, err = r.p.vault.Logical().Write(
-		fmt.Sprintf("%s/config/root", plan.VaultEnginePath.Value), secretEngineData)
+
+	// The existing access keys are deleted (or deactivated) once the new access key was seeded into Vault.
+	// Deleting them also makes room for the access key created by rotate-root.
+	err = r.removeExistingAccessKeys(ctx, plan, existingKeys)
 	if err != nil {
-		resp.Diagnostics.AddError("Error writing access key to the AWS backend", err.Error())
+		r.rollbackCreate(ctx, vaultClient, state, true, "Error removing the existing access keys", err, resp)
 		return
 	}
 
-	// Rotate the access key using the Vault API
-	// We need to retry this, as IAM might take a few seconds to become consistent
-	err = retry.Do(
-		func() error {
-			_, err = r.p.vault.Logical().Write(
-				fmt.Sprintf("%s/config/rotate-root", plan.VaultEnginePath.Value), map[string]interface{}{})
+	// Rotate the access key using the Vault API and take ownership of the new one
+	accessKeyID := state.AwsAccessKeyID.Value
+	if isRotationBlocked(plan, existingKeys) {
+		resp.Diagnostics.AddWarning("Access key not rotated", rotationBlockedDetail(plan))
+	} else {
+		accessKeyID, err = rotateRootCredentials(ctx, vaultClient, plan.VaultEnginePath.Value)
+		if err != nil {
+			r.rollbackCreate(ctx, vaultClient, state, true, "Error rotating the access key in Vault", err, resp)
+			return
+		}
+		tflog.Info(ctx, "Rotated AWS access key", map[string]interface{}{
+			"access_key_id": accessKeyID,
+		})
+	}
 
-			return err
-		},
-		retry.Delay(3*time.Second),
-		retry.Attempts(5),
-	)
-	if err != nil {
-		resp.Diagnostics.AddError("Error rotating the access key in Vault", err.Error())
+	// The secret engine is working now, with an access key that is only known to Vault (unless the rotation is
+	// blocked). So there's nothing to roll back anymore - if any of the following steps fail, the new access key is kept in the state.
+	state.AwsAccessKeyID = types.String{Value: accessKeyID}
+	state.VaultAccessKeyID = types.String{Value: accessKeyID}
+	diags = resp.State.Set(ctx, state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
 		return
 	}
 
-	// Fetch the ID of the new AWS access key that was created from Vault - as we want to take ownership of that one
-	vResp, err := r.p.vault.Logical().Read(fmt.Sprintf("%s/config/root", plan.VaultEnginePath.Value))
+	// IAM is eventually consistent, so wait until it lists the new access key
+	err = waitForAccessKey(ctx, r.p.iam, state.AwsIamUsername.Value, state.AwsAccessKeyID.Value)
 	if err != nil {
-		resp.Diagnostics.AddError("Error reading the new access key AwsAccessKeyID from Vault", err.Error())
+		resp.Diagnostics.AddError("Error waiting for the new access key", err.Error())
 		return
 	}
-	state.AwsAccessKeyID = types.String{Value: vResp.Data["access_key"].(string)}
-	tflog.Info(ctx, "Rotated AWS access key", map[string]interface{}{
-		"access_key_id": state.AwsAccessKeyID.Value,
-	})
-
-	// sleep for 10 seconds to ensure IAM reached consistency for the new access key
-	time.Sleep(10 * time.Second)
 
 	err = r.refreshState(ctx, &state)
 	if err != nil {
 		resp.Diagnostics.AddError("Could not refresh the state data", err.Error())
 		return
 	}
+	r.updateAccessKeyTag(ctx, state)
 
 	diags = resp.State.Set(ctx, state)
 	resp.Diagnostics.Append(diags...)
@@ -221,23 +620,130 @@ func (r resourceAwsSecretAccessKey) Create(ctx context.Context, req tfsdk.Create
 	}
 }
 
+// rollbackManagedIAMUser is called if Create fails before the access key was created. It deletes the managed IAM
+// user again (if any), and returns the outcome to be appended to the detail of the error.
+func (r resourceAwsSecretAccessKey) rollbackManagedIAMUser(plan AwsSecretAccessKey) string {
+	if plan.ManageIAMUser == nil {
+		return ""
+	}
+
+	// The deadline of the operation might be exceeded already, but the rollback must be performed anyway
+	rollbackCtx, cancel := context.WithTimeout(context.Background(), defaultDeleteTimeout)
+	defer cancel()
+
+	if err := r.deleteManagedIAMUser(rollbackCtx, plan.AwsIamUsername.Value); err != nil {
+		return fmt.Sprintf("\n\nThe rollback failed, the IAM user needs to be deleted manually: %v", err)
+	}
+	return fmt.Sprintf("\n\nThe IAM user %s was deleted again.", plan.AwsIamUsername.Value)
+}
+
+// rollbackCreate is called if Create fails after creating the access key, but before Vault rotated it. It deletes
+// the access keys that were created (including an access key that rotate-root might have created before failing),
+// clears the root credentials of the secret engine if they were written already, and deletes the managed IAM user.
+//
+// If the rollback succeeds, the resource is removed from the state. Otherwise, a remaining access key is kept in
+// the (tainted) state, so the next apply deletes it.
+func (r resourceAwsSecretAccessKey) rollbackCreate(ctx context.Context, vaultClient *vault.Client, state AwsSecretAccessKey, rootCredentialsWritten bool, summary string, cause error, resp *tfsdk.CreateResourceResponse) {
+	// The deadline of the operation might be exceeded already, but the rollback must be performed anyway
+	rollbackCtx, cancel := context.WithTimeout(context.Background(), defaultDeleteTimeout)
+	defer cancel()
+
+	enginePath := state.VaultEnginePath.Value
+	accessKeyIDs := []string{state.AwsAccessKeyID.Value}
+	var rolledBack, failed, remainingAccessKeyIDs []string
+
+	if rootCredentialsWritten {
+		// rotate-root might have created a new access key before failing (i.e. if the previous one couldn't be deleted)
+		vaultAccessKeyID, err := readRootAccessKeyID(rollbackCtx, vaultClient, enginePath)
+		if err == nil && vaultAccessKeyID != "" && vaultAccessKeyID != state.AwsAccessKeyID.Value {
+			accessKeyIDs = append(accessKeyIDs, vaultAccessKeyID)
+		}
+
+		err = writeRootCredentials(rollbackCtx, vaultClient, enginePath, "", "", nil)
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("- could not clear the root credentials of the secret engine %s: %v", enginePath, err))
+		} else {
+			rolledBack = append(rolledBack, fmt.Sprintf("- cleared the root credentials of the secret engine %s", enginePath))
+			tflog.Info(ctx, "Cleared the root credentials of the secret engine", map[string]interface{}{
+				"vault_engine_path": enginePath,
+			})
+		}
+	}
+
+	for _, accessKeyID := range accessKeyIDs {
+		err := deleteAccessKey(rollbackCtx, r.p.iam, state.AwsIamUsername.Value, accessKeyID)
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("- could not delete the access key %s: %v", accessKeyID, err))
+			remainingAccessKeyIDs = append(remainingAccessKeyIDs, accessKeyID)
+			continue
+		}
+
+		rolledBack = append(rolledBack, fmt.Sprintf("- deleted the access key %s of the IAM user %s", accessKeyID, state.AwsIamUsername.Value))
+		tflog.Info(ctx, "Deleted AWS access key", map[string]interface{}{
+			"access_key_id": accessKeyID,
+		})
+	}
+
+	if state.ManageIAMUser != nil && len(remainingAccessKeyIDs) == 0 {
+		err := r.deleteManagedIAMUser(rollbackCtx, state.AwsIamUsername.Value)
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("- could not delete the IAM user %s: %v", state.AwsIamUsername.Value, err))
+		} else {
+			rolledBack = append(rolledBack, fmt.Sprintf("- deleted the IAM user %s", state.AwsIamUsername.Value))
+		}
+	}
+
+	detail := cause.Error()
+	if len(rolledBack) > 0 {
+		detail += "\n\nThe following changes were rolled back:\n" + strings.Join(rolledBack, "\n")
+	}
+	if len(failed) > 0 {
+		detail += "\n\nThe rollback failed partially:\n" + strings.Join(failed, "\n")
+	}
+	if len(remainingAccessKeyIDs) > 0 {
+		detail += fmt.Sprintf("\n\nThe access key %s is kept in the state, so it is deleted during the next apply.", remainingAccessKeyIDs[0])
+	}
+	if len(remainingAccessKeyIDs) > 1 {
+		detail += fmt.Sprintf(" The following access keys need to be deleted manually: %s", strings.Join(remainingAccessKeyIDs[1:], ", "))
+	}
+	resp.Diagnostics.AddError(summary, detail)
+
+	if len(remainingAccessKeyIDs) == 0 {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
+	state.AwsAccessKeyID = types.String{Value: remainingAccessKeyIDs[0]}
+	diags := resp.State.Set(ctx, state)
+	resp.Diagnostics.Append(diags...)
+}
+
 func (r resourceAwsSecretAccessKey) refreshState(ctx context.Context, state *AwsSecretAccessKey) error {
+	vaultClient, err := r.vaultClient(state.VaultNamespace)
+	if err != nil {
+		return err
+	}
+
 	// Refresh the access key ID that is configured in the vault engine
-	vRead, err := r.p.vault.Logical().Read(fmt.Sprintf("%s/config/root", state.VaultEnginePath.Value))
+	vRead, err := vaultClient.Logical().ReadWithContext(ctx, fmt.Sprintf("%s/config/root", state.VaultEnginePath.Value))
 	if err != nil {
 		return err
 	}
+	if vRead == nil {
+		return fmt.Errorf("the configuration of the secret engine %s was not found (i.e. it is no longer mounted)", state.VaultEnginePath.Value)
+	}
 	state.VaultAccessKeyID = types.String{Value: vRead.Data["access_key"].(string)}
+	refreshRootConfig(state, vRead.Data)
 
-	// Find the Access Key in AWS and refresh its creation date
-	creationDate, err := getAwsAccessKeyCreationDate(ctx, r.p.iam, state.AwsIamUsername.Value, state.AwsAccessKeyID.Value)
+	// Find the Access Key in AWS and refresh its creation date and status
+	key, err := getAwsAccessKey(ctx, r.p.iam, state.AwsIamUsername.Value, state.AwsAccessKeyID.Value)
 	if errors.Is(err, ErrAccessKeyNotFound) && !state.VaultAccessKeyID.Equal(state.AwsAccessKeyID) {
 		// If the access key was removed from AWS and Vault was set to a different access key ID,
 		// it might mean that someone rotate the key in Vault. That is OK - and we will check if we can take ownership
 		// of the new access key in AWS.
 
 		// Let's check if the Access Key ID configured in Vault exists and is the only one
-		creationDate, err = getAwsAccessKeyCreationDate(ctx, r.p.iam, state.AwsIamUsername.Value, state.VaultAccessKeyID.Value)
+		key, err = getAwsAccessKey(ctx, r.p.iam, state.AwsIamUsername.Value, state.VaultAccessKeyID.Value)
 		if errors.Is(err, ErrAccessKeyNotFound) {
 			// If the access key does not exist, we can't take ownership of it
 			return ErrAccessKeyNotFound
@@ -259,15 +765,38 @@ func (r resourceAwsSecretAccessKey) refreshState(ctx context.Context, state *Aws
 				state.AwsAccessKeyID.Value, state.VaultAccessKeyID.Value, state.AwsIamUsername.Value)
 		}
 
-		// TODO: To be really secure, would be great if we trigger a key rotation in this case
 		state.AwsAccessKeyID.Value = state.VaultAccessKeyID.Value
-		tflog.Info(ctx, "The AWS access key apparently was rotated externally. Taking ownership of the new one", map[string]interface{}{
-			"access_key_id": state.AwsAccessKeyID.Value,
-		})
+		if isAutomatedRotationEnabled(*state) {
+			// Vault rotates the access key on its own, so this is expected
+			tflog.Debug(ctx, "The AWS access key was rotated automatically by Vault. Taking ownership of the new one", map[string]interface{}{
+				"access_key_id": state.AwsAccessKeyID.Value,
+			})
+		} else {
+			tflog.Info(ctx, "The AWS access key apparently was rotated externally. Taking ownership of the new one", map[string]interface{}{
+				"access_key_id": state.AwsAccessKeyID.Value,
+			})
+
+			// We can't be sure that the secret key of the adopted access key was never handled outside of Vault, so
+			// it is rotated once more during the next apply if rotate_on_adopt is enabled (see ModifyPlan)
+			state.Adopted = types.Bool{Value: true}
+		}
 	} else if err != nil {
 		return err
 	}
-	state.AwsAccessKeyCreationDate = types.String{Value: creationDate.Format(time.RFC3339)}
+	state.AwsAccessKeyCreationDate = types.String{Value: key.CreateDate.Format(time.RFC3339)}
+	state.Status = types.String{Value: string(key.Status)}
+
+	// The usage of the access key is only informational as well, so it doesn't fail the refresh either
+	lastUsed, err := r.p.iam.GetAccessKeyLastUsed(ctx, &iam.GetAccessKeyLastUsedInput{AccessKeyId: aws.String(state.AwsAccessKeyID.Value)})
+	if err != nil {
+		tflog.Warn(ctx, "Could not fetch the usage of the access key", map[string]interface{}{
+			"access_key_id": state.AwsAccessKeyID.Value,
+			"error":         err.Error(),
+		})
+		refreshLastUsed(state, nil)
+	} else {
+		refreshLastUsed(state, lastUsed.AccessKeyLastUsed)
+	}
 
 	return nil
 }
@@ -280,14 +809,33 @@ func (r resourceAwsSecretAccessKey) Read(ctx context.Context, req tfsdk.ReadReso
 		return
 	}
 
+	ctx, cancel := context.WithTimeout(ctx, state.Timeouts.read())
+	defer cancel()
+
+	previousAccessKeyID := state.AwsAccessKeyID.Value
 	err := r.refreshState(ctx, &state)
 	if errors.Is(err, ErrAccessKeyNotFound) {
-		resp.State.RemoveResource(ctx)
-		return
-	}
-	if err != nil {
+		if !state.AutoRepair.Value {
+			resp.State.RemoveResource(ctx)
+			return
+		}
+
+		// Keep the resource, so the access key is repaired in-place during the next apply (see ModifyPlan)
+		tflog.Warn(ctx, "The AWS access key no longer exists and will be repaired", map[string]interface{}{
+			"access_key_id": state.AwsAccessKeyID.Value,
+		})
+		state.AwsAccessKeyID = types.String{Null: true}
+		state.AwsAccessKeyCreationDate = types.String{Null: true}
+		state.Status = types.String{Null: true}
+		state.LastUsedDate = types.String{Null: true}
+		state.LastUsedService = types.String{Null: true}
+		state.LastUsedRegion = types.String{Null: true}
+	} else if err != nil {
 		resp.Diagnostics.AddError("Could not refresh the state data", err.Error())
 		return
+	} else if state.AwsAccessKeyID.Value != previousAccessKeyID {
+		// The access key was adopted (see refreshState)
+		r.updateAccessKeyTag(ctx, state)
 	}
 
 	diags = resp.State.Set(ctx, &state)
@@ -297,55 +845,423 @@ func (r resourceAwsSecretAccessKey) Read(ctx context.Context, req tfsdk.ReadReso
 	}
 }
 
-func (r resourceAwsSecretAccessKey) Update(_ context.Context, _ tfsdk.UpdateResourceRequest, _ *tfsdk.UpdateResourceResponse) {
-	panic("update method not implemented as all possible changes should result in resource replacement")
-}
-
-func (r resourceAwsSecretAccessKey) Delete(ctx context.Context, req tfsdk.DeleteResourceRequest, resp *tfsdk.DeleteResourceResponse) {
-	var state AwsSecretAccessKey
-	diags := req.State.Get(ctx, &state)
+// Update seeds a new access key if the secret engine or the IAM user changed or if the access key needs to be
+// repaired, or rotates it (see ModifyPlan). Changes of the configuration of the secret engine are written in-place.
+func (r resourceAwsSecretAccessKey) Update(ctx context.Context, req tfsdk.UpdateResourceRequest, resp *tfsdk.UpdateResourceResponse) {
+	var plan AwsSecretAccessKey
+	diags := req.Plan.Get(ctx, &plan)
 	resp.Diagnostics.Append(diags...)
 	if resp.Diagnostics.HasError() {
 		return
 	}
 
-	_, err := r.p.iam.DeleteAccessKey(ctx, &iam.DeleteAccessKeyInput{
-		UserName:    aws.String(state.AwsIamUsername.Value),
-		AccessKeyId: aws.String(state.AwsAccessKeyID.Value),
-	})
-	if err != nil {
-		resp.Diagnostics.AddError(
-			"Could not delete access key",
-			err.Error(),
-		)
+	var state AwsSecretAccessKey
+	diags = req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
 		return
 	}
 
-	resp.State.RemoveResource(ctx)
-}
+	ctx, cancel := context.WithTimeout(ctx, plan.Timeouts.update())
+	defer cancel()
 
-// ImportState expects the following conditions to be met:
-// - The Vault AWS secret engine is configured with an access key ID
-// - The AWS IAM user has a single access key configured, identical to the one in Vault
+	if state.ManageIAMUser != nil && plan.ManageIAMUser != nil {
+		err := r.updateManagedIAMUser(ctx, plan.AwsIamUsername.Value, state.ManageIAMUser, plan.ManageIAMUser)
+		if err != nil {
+			resp.Diagnostics.AddError("Error updating the IAM user", err.Error())
+			return
+		}
+	}
+
+	// A different secret engine is configured from scratch while seeding the new access key (see reseed)
+	isSameEngine := isSameVaultNamespace(state.VaultNamespace, plan.VaultNamespace) && state.VaultEnginePath.Equal(plan.VaultEnginePath)
+	if changes := rootConfigChanges(state, plan); len(changes) > 0 && isSameEngine {
+		vaultClient, err := r.vaultClient(plan.VaultNamespace)
+		if err != nil {
+			resp.Diagnostics.AddError("Unable to create Vault client", err.Error())
+			return
+		}
+
+		err = writeRootConfig(ctx, vaultClient, plan.VaultEnginePath.Value, changes)
+		if err != nil {
+			resp.Diagnostics.AddError("Error updating the configuration of the AWS backend", err.Error())
+			return
+		}
+		tflog.Info(ctx, "Updated the configuration of the secret engine", changes)
+	}
+
+	switch {
+	case isRelocated(state, plan) || isRepairNeeded(state):
+		r.reseed(ctx, state, plan, resp)
+	case plan.AwsAccessKeyID.Unknown:
+		r.rotate(ctx, state, plan, resp)
+	default:
+		// Only attributes without side effects were changed
+		diags = resp.State.Set(ctx, plan)
+		resp.Diagnostics.Append(diags...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+}
+
+// reseed moves the access key to a different secret engine or IAM user, or repairs it
+//
+// As the secret key is only known to Vault, we can't simply pass it to the new secret engine. Instead, a new access key is created and configured in the (new)
+// secret engine, before the previous access key is deleted and the previous secret engine is cleared. Finally,
+// the new access key is rotated using Vault (as in Create).
+func (r resourceAwsSecretAccessKey) reseed(ctx context.Context, state AwsSecretAccessKey, plan AwsSecretAccessKey, resp *tfsdk.UpdateResourceResponse) {
+	previousVaultClient, err := r.vaultClient(state.VaultNamespace)
+	if err != nil {
+		resp.Diagnostics.AddError("Unable to create Vault client", err.Error())
+		return
+	}
+	vaultClient, err := r.vaultClient(plan.VaultNamespace)
+	if err != nil {
+		resp.Diagnostics.AddError("Unable to create Vault client", err.Error())
+		return
+	}
+
+	// The existing access keys of a different IAM user (or if the previous access key is missing) are handled as
+	// in Create
+	var existingKeys []iamTypes.AccessKeyMetadata
+	if plan.AwsIamUsername.Value != state.AwsIamUsername.Value || state.AwsAccessKeyID.Null {
+		var diags diag.Diagnostics
+		existingKeys, diags = r.existingAccessKeys(ctx, plan)
+		resp.Diagnostics.Append(diags...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	key, err := r.p.iam.CreateAccessKey(ctx, &iam.CreateAccessKeyInput{UserName: aws.String(plan.AwsIamUsername.Value)})
+	if err != nil {
+		resp.Diagnostics.AddError("Error creating access key", err.Error())
+		return
+	}
+	tflog.Info(ctx, "Created AWS access key", map[string]interface{}{
+		"access_key_id": *key.AccessKey.AccessKeyId,
+	})
+
+	err = writeRootCredentials(ctx, vaultClient, plan.VaultEnginePath.Value, *key.AccessKey.AccessKeyId, *key.AccessKey.SecretAccessKey, plan.rootConfig())
+	if err != nil {
+		resp.Diagnostics.AddError("Error writing access key to the AWS backend", err.Error())
+
+		_, err = r.p.iam.DeleteAccessKey(ctx, &iam.DeleteAccessKeyInput{
+			UserName:    key.AccessKey.UserName,
+			AccessKeyId: key.AccessKey.AccessKeyId,
+		})
+		if err != nil {
+			resp.Diagnostics.AddError("Could not delete the new access key",
+				fmt.Sprintf("The access key (ID: %s) needs to be deleted manually: %v", *key.AccessKey.AccessKeyId, err))
+		}
+		return
+	}
+
+	// From now on, the new secret engine is using the new access key - so this resource is tracking that one
+	newState := plan
+	newState.ID = types.String{Value: awsSecretAccessKeyID(plan.VaultNamespace, plan.VaultEnginePath, plan.AwsIamUsername)}
+	newState.AwsAccessKeyID = types.String{Value: *key.AccessKey.AccessKeyId}
+	newState.AwsAccessKeyCreationDate = types.String{Value: key.AccessKey.CreateDate.Format(time.RFC3339)}
+	newState.Status = types.String{Value: string(key.AccessKey.Status)}
+	newState.LastUsedDate = types.String{Null: true}
+	newState.LastUsedService = types.String{Null: true}
+	newState.LastUsedRegion = types.String{Null: true}
+	newState.VaultAccessKeyID = types.String{Value: *key.AccessKey.AccessKeyId}
+	diags := resp.State.Set(ctx, newState)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	err = r.removeExistingAccessKeys(ctx, plan, existingKeys)
+	if err != nil {
+		resp.Diagnostics.AddError("Error removing the existing access keys", err.Error())
+		return
+	}
+
+	// Remove the previous access key (unless it's missing already). This is also required to make room for the
+	// access key created by rotate-root, as an IAM user can have at most two access keys.
+	if !state.AwsAccessKeyID.Null {
+		err = deleteAccessKey(ctx, r.p.iam, state.AwsIamUsername.Value, state.AwsAccessKeyID.Value)
+		if err != nil {
+			resp.Diagnostics.AddError(
+				"Could not delete the previous access key",
+				fmt.Sprintf("The previous access key (ID: %s) of the IAM user %s could not be deleted: %v",
+					state.AwsAccessKeyID.Value, state.AwsIamUsername.Value, err),
+			)
+			return
+		}
+		tflog.Info(ctx, "Deleted previous AWS access key", map[string]interface{}{
+			"access_key_id": state.AwsAccessKeyID.Value,
+		})
+	}
+
+	// If the IAM user didn't change, the description tag of the previous access key is removed when the new access
+	// key is tagged below
+	if plan.AwsIamUsername.Value != state.AwsIamUsername.Value {
+		err = r.untagAccessKeys(ctx, state.AwsIamUsername.Value, "")
+		if err != nil {
+			tflog.Warn(ctx, "Could not remove the description tag of the previous access key from the IAM user", map[string]interface{}{
+				"access_key_id": state.AwsAccessKeyID.Value,
+				"error":         err.Error(),
+			})
+		}
+	}
+
+	// Clear the previous secret engine, so it doesn't hand out broken credentials. The secret engine might
+	// already be gone (i.e. if it was replaced), so this is not considered to be an error.
+	if state.VaultNamespace.Value != plan.VaultNamespace.Value || state.VaultEnginePath.Value != plan.VaultEnginePath.Value {
+		err = writeRootCredentials(ctx, previousVaultClient, state.VaultEnginePath.Value, "", "", nil)
+		if err != nil {
+			resp.Diagnostics.AddWarning(
+				"Could not clear the previous secret engine",
+				fmt.Sprintf("The root credentials of the secret engine %s could not be cleared: %v", state.VaultEnginePath.Value, err),
+			)
+		}
+	}
+
+	if isRotationBlocked(plan, existingKeys) {
+		resp.Diagnostics.AddWarning("Access key not rotated", rotationBlockedDetail(plan))
+	} else {
+		accessKeyID, err := rotateRootCredentials(ctx, vaultClient, plan.VaultEnginePath.Value)
+		if err != nil {
+			resp.Diagnostics.AddError("Error rotating the access key in Vault", err.Error())
+			return
+		}
+		newState.AwsAccessKeyID = types.String{Value: accessKeyID}
+		tflog.Info(ctx, "Rotated AWS access key", map[string]interface{}{
+			"access_key_id": newState.AwsAccessKeyID.Value,
+		})
+	}
+
+	// IAM is eventually consistent, so wait until it lists the new access key
+	err = waitForAccessKey(ctx, r.p.iam, newState.AwsIamUsername.Value, newState.AwsAccessKeyID.Value)
+	if err != nil {
+		resp.Diagnostics.AddError("Error waiting for the new access key", err.Error())
+		return
+	}
+
+	err = r.refreshState(ctx, &newState)
+	if err != nil {
+		resp.Diagnostics.AddError("Could not refresh the state data", err.Error())
+		return
+	}
+	r.updateAccessKeyTag(ctx, newState)
+
+	diags = resp.State.Set(ctx, newState)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+}
+
+// rotate rotates the access key using the rotate-root API of Vault and takes ownership of the new access key
+func (r resourceAwsSecretAccessKey) rotate(ctx context.Context, state AwsSecretAccessKey, plan AwsSecretAccessKey, resp *tfsdk.UpdateResourceResponse) {
+	vaultClient, err := r.vaultClient(state.VaultNamespace)
+	if err != nil {
+		resp.Diagnostics.AddError("Unable to create Vault client", err.Error())
+		return
+	}
+
+	accessKeyID, err := rotateRootCredentials(ctx, vaultClient, state.VaultEnginePath.Value)
+	if err != nil {
+		resp.Diagnostics.AddError("Error rotating the access key in Vault", err.Error())
+		return
+	}
+	tflog.Info(ctx, "Rotated AWS access key", map[string]interface{}{
+		"access_key_id": accessKeyID,
+	})
+
+	newState := plan
+	newState.ID = state.ID
+	newState.AwsAccessKeyID = types.String{Value: accessKeyID}
+	newState.AwsAccessKeyCreationDate = state.AwsAccessKeyCreationDate
+	newState.Status = state.Status
+	newState.LastUsedDate = state.LastUsedDate
+	newState.LastUsedService = state.LastUsedService
+	newState.LastUsedRegion = state.LastUsedRegion
+	newState.VaultAccessKeyID = state.VaultAccessKeyID
+
+	// IAM is eventually consistent, so wait until it lists the new access key
+	err = waitForAccessKey(ctx, r.p.iam, newState.AwsIamUsername.Value, newState.AwsAccessKeyID.Value)
+	if err != nil {
+		resp.Diagnostics.AddError("Error waiting for the new access key", err.Error())
+		return
+	}
+
+	err = r.refreshState(ctx, &newState)
+	if err != nil {
+		resp.Diagnostics.AddError("Could not refresh the state data", err.Error())
+		return
+	}
+	r.updateAccessKeyTag(ctx, newState)
+
+	diags := resp.State.Set(ctx, newState)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+}
+
+// Delete deletes, deactivates or retains the access key (see on_destroy), after optionally clearing the root
+// credentials of the secret engine. A managed IAM user is deleted as well.
+func (r resourceAwsSecretAccessKey) Delete(ctx context.Context, req tfsdk.DeleteResourceRequest, resp *tfsdk.DeleteResourceResponse) {
+	var state AwsSecretAccessKey
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, state.Timeouts.delete())
+	defer cancel()
+
+	// Clear the secret engine first, so it doesn't hand out credentials of an access key that is about to be
+	// removed. The secret engine might already be gone, so this is not considered to be an error.
+	if state.ClearRootCredentialsOnDestroy.Value {
+		vaultClient, err := r.vaultClient(state.VaultNamespace)
+		if err != nil {
+			resp.Diagnostics.AddError("Unable to create Vault client", err.Error())
+			return
+		}
+
+		err = writeRootCredentials(ctx, vaultClient, state.VaultEnginePath.Value, "", "", nil)
+		if err != nil {
+			resp.Diagnostics.AddWarning(
+				"Could not clear the secret engine",
+				fmt.Sprintf("The root credentials of the secret engine %s could not be cleared: %v", state.VaultEnginePath.Value, err),
+			)
+		} else {
+			tflog.Info(ctx, "Cleared the root credentials of the secret engine", map[string]interface{}{
+				"vault_engine_path": state.VaultEnginePath.Value,
+			})
+		}
+	}
+
+	switch {
+	case state.AwsAccessKeyID.Null:
+		// With auto_repair, the resource is kept in the state after its access key was deleted (see Read)
+		tflog.Info(ctx, "The AWS access key no longer exists, so there is nothing to remove")
+	case state.OnDestroy.Value == onDestroyRetain:
+		tflog.Info(ctx, "Retaining AWS access key", map[string]interface{}{
+			"access_key_id": state.AwsAccessKeyID.Value,
+		})
+	case state.OnDestroy.Value == onDestroyDeactivate:
+		_, err := r.p.iam.UpdateAccessKey(ctx, &iam.UpdateAccessKeyInput{
+			UserName:    aws.String(state.AwsIamUsername.Value),
+			AccessKeyId: aws.String(state.AwsAccessKeyID.Value),
+			Status:      iamTypes.StatusTypeInactive,
+		})
+		var notFound *iamTypes.NoSuchEntityException
+		if err != nil && !errors.As(err, &notFound) {
+			resp.Diagnostics.AddError(
+				"Could not deactivate access key",
+				err.Error(),
+			)
+			return
+		}
+		tflog.Info(ctx, "Deactivated AWS access key", map[string]interface{}{
+			"access_key_id": state.AwsAccessKeyID.Value,
+		})
+	default:
+		err := deleteAccessKey(ctx, r.p.iam, state.AwsIamUsername.Value, state.AwsAccessKeyID.Value)
+		if err != nil {
+			resp.Diagnostics.AddError(
+				"Could not delete access key",
+				err.Error(),
+			)
+			return
+		}
+		tflog.Info(ctx, "Deleted AWS access key", map[string]interface{}{
+			"access_key_id": state.AwsAccessKeyID.Value,
+		})
+	}
+
+	if state.ManageIAMUser != nil {
+		err := r.deleteManagedIAMUser(ctx, state.AwsIamUsername.Value)
+		if err != nil {
+			resp.Diagnostics.AddError("Could not delete the IAM user", err.Error())
+			return
+		}
+	} else if !state.AwsAccessKeyID.Null {
+		// The access key is no longer managed by this resource
+		err := r.untagAccessKeys(ctx, state.AwsIamUsername.Value, "")
+		if err != nil {
+			tflog.Warn(ctx, "Could not remove the description tag of the access key from the IAM user", map[string]interface{}{
+				"access_key_id": state.AwsAccessKeyID.Value,
+				"error":         err.Error(),
+			})
+		}
+	}
+
+	resp.State.RemoveResource(ctx)
+}
+
+// ImportState expects the following conditions to be met:
+// - The Vault AWS secret engine is configured with an access key ID
+// - The AWS IAM user has a single access key configured, identical to the one in Vault
 //
 // If all checks succeed, we will also perform an access key rotation before finishing the import
 func (r resourceAwsSecretAccessKey) ImportState(ctx context.Context, req tfsdk.ImportResourceStateRequest, resp *tfsdk.ImportResourceStateResponse) {
+	ctx, cancel := context.WithTimeout(ctx, defaultImportTimeout)
+	defer cancel()
+
 	idParts := strings.Split(req.ID, ":")
 
-	if len(idParts) != 2 || idParts[0] == "" || idParts[1] == "" {
+	// The vault namespace is an optional prefix of the ID
+	vaultNamespace := types.String{Null: true}
+	if len(idParts) == 3 {
+		vaultNamespace = types.String{Value: idParts[0]}
+		idParts = idParts[1:]
+	}
+
+	if len(idParts) != 2 || vaultNamespace.Value == "" && !vaultNamespace.Null || idParts[0] == "" || idParts[1] == "" {
 		resp.Diagnostics.AddError("Invalid ID format",
-			fmt.Sprintf("Expected import identifier to be in the format '<vault_engine_path>:<aws_iam_username>', got '%s'", req.ID))
+			fmt.Sprintf("Expected import identifier to be in the format '[<vault_namespace>:]<vault_engine_path>:<aws_iam_username>', got '%s'", req.ID))
 		return
 	}
 
+	// All other attributes can't be derived from the ID and are initialized as null, so that they match an
+	// unset configuration
 	state := AwsSecretAccessKey{
-		ID:              types.String{Value: req.ID},
-		VaultEnginePath: types.String{Value: idParts[0]},
-		AwsIamUsername:  types.String{Value: idParts[1]},
+		ID:                            types.String{Value: req.ID},
+		AwsIamUsername:                types.String{Value: idParts[1]},
+		AwsAccessKeyCreationDate:      types.String{Null: true},
+		Status:                        types.String{Null: true},
+		LastUsedDate:                  types.String{Null: true},
+		LastUsedService:               types.String{Null: true},
+		LastUsedRegion:                types.String{Null: true},
+		Adopted:                       types.Bool{Value: false},
+		RotationInterval:              types.String{Null: true},
+		RotationTriggers:              types.Map{ElemType: types.StringType, Null: true},
+		VaultNamespace:                vaultNamespace,
+		VaultEnginePath:               types.String{Value: idParts[0]},
+		Region:                        types.String{Null: true},
+		IAMEndpoint:                   types.String{Null: true},
+		STSEndpoint:                   types.String{Null: true},
+		STSRegion:                     types.String{Null: true},
+		MaxRetries:                    types.Int64{Null: true},
+		UsernameTemplate:              types.String{Null: true},
+		RotationPeriod:                types.Int64{Null: true},
+		RotationSchedule:              types.String{Null: true},
+		RotationWindow:                types.Int64{Null: true},
+		DisableAutomatedRotation:      types.Bool{Null: true},
+		ExistingKeys:                  types.String{Null: true},
+		AutoRepair:                    types.Bool{Null: true},
+		RotateOnAdopt:                 types.Bool{Null: true},
+		OnDestroy:                     types.String{Null: true},
+		ClearRootCredentialsOnDestroy: types.Bool{Null: true},
+	}
+
+	vaultClient, err := r.vaultClient(state.VaultNamespace)
+	if err != nil {
+		resp.Diagnostics.AddError("Unable to create Vault client", err.Error())
+		return
 	}
 
 	// Read used access key ID from Vault
-	vResp, err := r.p.vault.Logical().Read(fmt.Sprintf("%s/config/root", state.VaultEnginePath.Value))
+	vResp, err := vaultClient.Logical().ReadWithContext(ctx, fmt.Sprintf("%s/config/root", state.VaultEnginePath.Value))
 	if err != nil {
 		resp.Diagnostics.AddError("Error reading the access key ID from Vault", err.Error())
 		return
@@ -371,26 +1287,25 @@ func (r resourceAwsSecretAccessKey) ImportState(ctx context.Context, req tfsdk.I
 	state.AwsAccessKeyID = types.String{Value: *iamResp.AccessKeyMetadata[0].AccessKeyId}
 
 	// As we are not sure if the access key secret was leaked outside of Vault, we will trigger a key rotation now
-	_, err = r.p.vault.Logical().Write(
-		fmt.Sprintf("%s/config/rotate-root", state.VaultEnginePath.Value), map[string]interface{}{})
+	accessKeyID, err := rotateRootCredentials(ctx, vaultClient, state.VaultEnginePath.Value)
 	if err != nil {
 		resp.Diagnostics.AddError("Error rotating the access key ID in Vault", err.Error())
 		return
 	}
+	state.AwsAccessKeyID = types.String{Value: accessKeyID}
 
-	// Fetch the ID of the new AWS access key that was created from Vault - as we want to take ownership of that one
-	vResp, err = r.p.vault.Logical().Read(fmt.Sprintf("%s/config/root", state.VaultEnginePath.Value))
+	err = waitForAccessKey(ctx, r.p.iam, state.AwsIamUsername.Value, state.AwsAccessKeyID.Value)
 	if err != nil {
-		resp.Diagnostics.AddError("Error reading the new access key AwsAccessKeyID from Vault", err.Error())
+		resp.Diagnostics.AddError("Error waiting for the new access key", err.Error())
 		return
 	}
-	state.AwsAccessKeyID = types.String{Value: vResp.Data["access_key"].(string)}
 
 	err = r.refreshState(ctx, &state)
 	if err != nil {
 		resp.Diagnostics.AddError("Could not refresh the state data", err.Error())
 		return
 	}
+	r.updateAccessKeyTag(ctx, state)
 
 	diags := resp.State.Set(ctx, state)
 	resp.Diagnostics.Append(diags...)
@@ -399,7 +1314,468 @@ func (r resourceAwsSecretAccessKey) ImportState(ctx context.Context, req tfsdk.I
 	}
 }
 
-func getAwsAccessKeyCreationDate(ctx context.Context, iamClient *iam.Client, username string, accessKeyID string) (*time.Time, error) {
+// existingAccessKeys returns the access keys that the IAM user has before this resource creates one. Depending on
+// existing_keys, existing access keys are either not allowed (fail), or returned to be deleted or deactivated once
+// the new access key was seeded into Vault (delete or deactivate, see removeExistingAccessKeys).
+//
+// If the IAM user is managed by this resource, it might have been created just before, so listing its access keys
+// is retried until IAM is consistent (see retryForNewIAMUser).
+//
+// If the IAM user already has the maximum number of access keys, one of them has to go before the new access key
+// can be created. With delete, it's deleted right away (inactive access keys are preferred, then the oldest one).
+// Deactivating it wouldn't make room, as inactive access keys count towards the limit, so deactivate fails instead.
+func (r resourceAwsSecretAccessKey) existingAccessKeys(ctx context.Context, plan AwsSecretAccessKey) ([]iamTypes.AccessKeyMetadata, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	var keys *iam.ListAccessKeysOutput
+	listAccessKeys := func() (err error) {
+		keys, err = r.p.iam.ListAccessKeys(ctx, &iam.ListAccessKeysInput{UserName: aws.String(plan.AwsIamUsername.Value)})
+		return err
+	}
+	var err error
+	if plan.ManageIAMUser != nil {
+		err = retryForNewIAMUser(ctx, listAccessKeys)
+	} else {
+		err = listAccessKeys()
+	}
+	if err != nil {
+		diags.AddError(
+			"Failed to get existing access keys",
+			fmt.Sprintf("Failed to fetch the list of existing access keys for the given IAM user: %v", err),
+		)
+		return nil, diags
+	}
+	existingKeys := keys.AccessKeyMetadata
+	if len(existingKeys) == 0 {
+		return nil, diags
+	}
+
+	if plan.ExistingKeys.Value != existingKeysDelete && plan.ExistingKeys.Value != existingKeysDeactivate {
+		diags.AddError(
+			"Existing access key detected",
+			"At least one existing access key was found on the specified IAM user. This is not allowed, as the access key "+
+				"will be created by this resources and rotated by Vault. Set existing_keys to \"delete\" or \"deactivate\" "+
+				"to delete or deactivate the existing access keys instead.",
+		)
+		return nil, diags
+	}
+
+	if len(existingKeys) >= maxAccessKeysPerUser && plan.ExistingKeys.Value == existingKeysDeactivate {
+		diags.AddError(
+			"Too many existing access keys",
+			fmt.Sprintf("The IAM user %s already has the maximum of %d access keys, so one of them has to be deleted "+
+				"before a new access key can be created. Deactivated access keys count towards the limit as well. "+
+				"Delete one of the access keys, or set existing_keys to \"delete\".", plan.AwsIamUsername.Value, maxAccessKeysPerUser),
+		)
+		return nil, diags
+	}
+
+	if len(existingKeys) >= maxAccessKeysPerUser {
+		sort.Slice(existingKeys, func(i, j int) bool {
+			if existingKeys[i].Status != existingKeys[j].Status {
+				return existingKeys[i].Status == iamTypes.StatusTypeInactive
+			}
+			return existingKeys[i].CreateDate.Before(*existingKeys[j].CreateDate)
+		})
+
+		err = r.deleteExistingAccessKeys(ctx, plan.AwsIamUsername.Value, existingKeys[:1])
+		if err != nil {
+			diags.AddError("Error deleting the existing access keys", err.Error())
+			return nil, diags
+		}
+		existingKeys = existingKeys[1:]
+	}
+
+	return existingKeys, diags
+}
+
+// removeExistingAccessKeys deletes or deactivates the given access keys that the IAM user had before this resource
+// created one, depending on existing_keys
+func (r resourceAwsSecretAccessKey) removeExistingAccessKeys(ctx context.Context, plan AwsSecretAccessKey, keys []iamTypes.AccessKeyMetadata) error {
+	if plan.ExistingKeys.Value == existingKeysDeactivate {
+		return r.deactivateExistingAccessKeys(ctx, plan.AwsIamUsername.Value, keys)
+	}
+
+	return r.deleteExistingAccessKeys(ctx, plan.AwsIamUsername.Value, keys)
+}
+
+// deleteExistingAccessKeys deletes the given access keys that the IAM user had before this resource created one
+func (r resourceAwsSecretAccessKey) deleteExistingAccessKeys(ctx context.Context, username string, keys []iamTypes.AccessKeyMetadata) error {
+	for _, key := range keys {
+		err := deleteAccessKey(ctx, r.p.iam, username, *key.AccessKeyId)
+		if err != nil {
+			return fmt.Errorf("the existing access key (ID: %s) of the IAM user %s could not be deleted: %w", *key.AccessKeyId, username, err)
+		}
+
+		tflog.Info(ctx, "Deleted existing AWS access key", map[string]interface{}{
+			"access_key_id": *key.AccessKeyId,
+			"status":        string(key.Status),
+		})
+	}
+
+	return nil
+}
+
+// deactivateExistingAccessKeys deactivates the given access keys that the IAM user had before this resource
+// created one
+func (r resourceAwsSecretAccessKey) deactivateExistingAccessKeys(ctx context.Context, username string, keys []iamTypes.AccessKeyMetadata) error {
+	for _, key := range keys {
+		if key.Status == iamTypes.StatusTypeInactive {
+			continue
+		}
+
+		_, err := r.p.iam.UpdateAccessKey(ctx, &iam.UpdateAccessKeyInput{
+			UserName:    aws.String(username),
+			AccessKeyId: key.AccessKeyId,
+			Status:      iamTypes.StatusTypeInactive,
+		})
+		if err != nil {
+			return fmt.Errorf("the existing access key (ID: %s) of the IAM user %s could not be deactivated: %w", *key.AccessKeyId, username, err)
+		}
+
+		tflog.Info(ctx, "Deactivated existing AWS access key", map[string]interface{}{
+			"access_key_id": *key.AccessKeyId,
+		})
+	}
+
+	return nil
+}
+
+// isRotationBlocked checks if existing access keys of the IAM user were kept deactivated (see existing_keys). As
+// they count towards the limit of access keys of the IAM user, Vault can't rotate the access key while they exist.
+func isRotationBlocked(plan AwsSecretAccessKey, existingKeys []iamTypes.AccessKeyMetadata) bool {
+	return plan.ExistingKeys.Value == existingKeysDeactivate && len(existingKeys) > 0
+}
+
+// rotationBlockedDetail explains why the access key was not rotated by Vault (see isRotationBlocked)
+func rotationBlockedDetail(plan AwsSecretAccessKey) string {
+	return fmt.Sprintf("The existing access keys of the IAM user %s were deactivated, and count towards the limit of "+
+		"%d access keys. Therefore, Vault can't rotate the access key that was seeded into the secret engine %s. "+
+		"Delete the deactivated access keys once they are no longer needed, and rotate the access key (i.e. by "+
+		"changing rotation_triggers).", plan.AwsIamUsername.Value, maxAccessKeysPerUser, plan.VaultEnginePath.Value)
+}
+
+// isRepairNeeded checks if the access key is missing, or if the secret engine is configured with a different
+// access key. ModifyPlan only plans an update in these cases if the access key can be repaired (see auto_repair).
+func isRepairNeeded(state AwsSecretAccessKey) bool {
+	return state.AwsAccessKeyID.Null || !state.AwsAccessKeyID.Equal(state.VaultAccessKeyID)
+}
+
+// isMissingMountError checks if reading a mount (sys/mounts/<path>) failed because nothing is mounted at the path.
+// Vault responds with 404, or with 400 and a corresponding error message. Other client errors (i.e. 403 if the
+// token is not allowed to read the mount) don't tell if the mount exists.
+func isMissingMountError(err error) bool {
+	var responseErr *vault.ResponseError
+	if !errors.As(err, &responseErr) {
+		return false
+	}
+	if responseErr.StatusCode == http.StatusNotFound {
+		return true
+	}
+	if responseErr.StatusCode != http.StatusBadRequest {
+		return false
+	}
+
+	for _, message := range responseErr.Errors {
+		if strings.HasPrefix(strings.ToLower(message), "no secret engine mount at") {
+			return true
+		}
+	}
+	return false
+}
+
+// isAccessKeyMissing checks if the given access key doesn't exist for the IAM user. If that can't be determined,
+// the access key is not considered to be missing.
+func (r resourceAwsSecretAccessKey) isAccessKeyMissing(ctx context.Context, username string, accessKeyID string) bool {
+	_, err := getAwsAccessKey(ctx, r.p.iam, username, accessKeyID)
+	return errors.Is(err, ErrAccessKeyNotFound)
+}
+
+// isRelocated checks if the secret engine or the IAM user of the resource changed
+func isRelocated(state AwsSecretAccessKey, plan AwsSecretAccessKey) bool {
+	return !isSameVaultNamespace(state.VaultNamespace, plan.VaultNamespace) ||
+		!state.VaultEnginePath.Equal(plan.VaultEnginePath) ||
+		!state.AwsIamUsername.Equal(plan.AwsIamUsername)
+}
+
+// isSameVaultNamespace checks if two values of vault_namespace refer to the same namespace. An empty namespace
+// and no namespace at all both refer to the namespace configured in the provider (see vaultClient).
+func isSameVaultNamespace(a types.String, b types.String) bool {
+	return !a.Unknown && !b.Unknown && a.Value == b.Value
+}
+
+// isRotationDue checks if the access key is older than the rotation interval
+func isRotationDue(state AwsSecretAccessKey, plan AwsSecretAccessKey) bool {
+	if plan.RotationInterval.Null || plan.RotationInterval.Unknown || state.AwsAccessKeyCreationDate.Value == "" {
+		return false
+	}
+
+	// Both values are validated already - the interval in the schema, and the creation date was written by us
+	rotationInterval, err := time.ParseDuration(plan.RotationInterval.Value)
+	if err != nil {
+		return false
+	}
+	creationDate, err := time.Parse(time.RFC3339, state.AwsAccessKeyCreationDate.Value)
+	if err != nil {
+		return false
+	}
+
+	return time.Since(creationDate) >= rotationInterval
+}
+
+// writeRootCredentials configures the given access key as root credentials of the secret engine, together with
+// the given configuration (see rootConfig). Empty credentials clear the credentials.
+//
+// Note that Vault merges the written fields into the existing configuration, so fields that are omitted keep
+// their previous value (see rootConfigChanges).
+func writeRootCredentials(ctx context.Context, vaultClient *vault.Client, enginePath string, accessKeyID string, secretAccessKey string, config map[string]interface{}) error {
+	data := map[string]interface{}{
+		"access_key": accessKeyID,
+		"secret_key": secretAccessKey,
+	}
+	for field, value := range config {
+		data[field] = value
+	}
+
+	_, err := vaultClient.Logical().WriteWithContext(ctx, fmt.Sprintf("%s/config/root", enginePath), data)
+	return err
+}
+
+// writeRootConfig writes the given fields to the configuration of the secret engine. The root credentials and
+// all other fields are kept, as Vault merges the written fields into the existing configuration.
+func writeRootConfig(ctx context.Context, vaultClient *vault.Client, enginePath string, config map[string]interface{}) error {
+	_, err := vaultClient.Logical().WriteWithContext(ctx, fmt.Sprintf("%s/config/root", enginePath), config)
+	return err
+}
+
+// rootConfig returns the configuration of the secret engine (besides the credentials) that is managed by this
+// resource. Attributes that are not set are omitted, so Vault uses its defaults.
+func (m AwsSecretAccessKey) rootConfig() map[string]interface{} {
+	config := map[string]interface{}{}
+
+	for field, value := range m.rootConfigStrings() {
+		if !value.Null && !value.Unknown {
+			config[field] = value.Value
+		}
+	}
+	for field, value := range m.rootConfigInt64s() {
+		if !value.Null && !value.Unknown {
+			config[field] = value.Value
+		}
+	}
+	if !m.DisableAutomatedRotation.Null && !m.DisableAutomatedRotation.Unknown {
+		config["disable_automated_rotation"] = m.DisableAutomatedRotation.Value
+	}
+
+	return config
+}
+
+// rootConfigStrings returns pointers to the string attributes of the model that are part of the configuration
+// of the secret engine, by their field name in Vault
+func (m *AwsSecretAccessKey) rootConfigStrings() map[string]*types.String {
+	return map[string]*types.String{
+		"region":            &m.Region,
+		"iam_endpoint":      &m.IAMEndpoint,
+		"sts_endpoint":      &m.STSEndpoint,
+		"sts_region":        &m.STSRegion,
+		"username_template": &m.UsernameTemplate,
+		"rotation_schedule": &m.RotationSchedule,
+	}
+}
+
+// rootConfigInt64s returns pointers to the number attributes of the model that are part of the configuration
+// of the secret engine, by their field name in Vault
+func (m *AwsSecretAccessKey) rootConfigInt64s() map[string]*types.Int64 {
+	return map[string]*types.Int64{
+		"max_retries":     &m.MaxRetries,
+		"rotation_period": &m.RotationPeriod,
+		"rotation_window": &m.RotationWindow,
+	}
+}
+
+// rootConfigDefaults are the values of the fields of the configuration of the secret engine that Vault uses if
+// they're not set. As Vault keeps fields that are omitted, they're written explicitly if an attribute is removed.
+var rootConfigDefaults = map[string]interface{}{
+	"region":            "",
+	"iam_endpoint":      "",
+	"sts_endpoint":      "",
+	"sts_region":        "",
+	"max_retries":       int64(-1),
+	"username_template": "",
+
+	"rotation_period":            int64(0),
+	"rotation_schedule":          "",
+	"rotation_window":            int64(0),
+	"disable_automated_rotation": false,
+}
+
+// rootConfigChanges returns the fields of the configuration of the secret engine that changed between the state
+// and the plan, together with their new value (or the default of Vault, if the attribute was removed)
+func rootConfigChanges(state AwsSecretAccessKey, plan AwsSecretAccessKey) map[string]interface{} {
+	stateConfig := state.rootConfig()
+	planConfig := plan.rootConfig()
+
+	changes := map[string]interface{}{}
+	for field, defaultValue := range rootConfigDefaults {
+		planValue, inPlan := planConfig[field]
+		stateValue, inState := stateConfig[field]
+		switch {
+		case inPlan && (!inState || planValue != stateValue):
+			changes[field] = planValue
+		case !inPlan && inState:
+			changes[field] = defaultValue
+		}
+	}
+
+	return changes
+}
+
+// isAutomatedRotationEnabled checks if Vault is configured to rotate the access key on its own
+func isAutomatedRotationEnabled(state AwsSecretAccessKey) bool {
+	if state.DisableAutomatedRotation.Value {
+		return false
+	}
+
+	return state.RotationPeriod.Value > 0 || state.RotationSchedule.Value != ""
+}
+
+// refreshRootConfig updates the configuration of the secret engine in the state with the values read from Vault.
+// Only attributes that are set are refreshed, so the configuration that is not managed by this resource
+// doesn't cause a drift.
+func refreshRootConfig(state *AwsSecretAccessKey, data map[string]interface{}) {
+	for field, value := range state.rootConfigStrings() {
+		if value.Null {
+			continue
+		}
+		if vaultValue, ok := data[field].(string); ok {
+			value.Value = vaultValue
+		}
+	}
+
+	for field, value := range state.rootConfigInt64s() {
+		if value.Null {
+			continue
+		}
+		if vaultValue, ok := data[field].(json.Number); ok {
+			if number, err := vaultValue.Int64(); err == nil {
+				value.Value = number
+			}
+		}
+	}
+
+	if !state.DisableAutomatedRotation.Null {
+		if vaultValue, ok := data["disable_automated_rotation"].(bool); ok {
+			state.DisableAutomatedRotation.Value = vaultValue
+		}
+	}
+}
+
+// rotateRootCredentials rotates the root credentials of the secret engine, and returns the ID of the new
+// access key that was created by Vault
+func rotateRootCredentials(ctx context.Context, vaultClient *vault.Client, enginePath string) (string, error) {
+	// We need to retry this until the deadline, as IAM might take a while to become consistent for the
+	// access key configured in Vault
+	err := retryUntilDeadline(ctx, func() error {
+		_, err := vaultClient.Logical().WriteWithContext(ctx,
+			fmt.Sprintf("%s/config/rotate-root", enginePath), map[string]interface{}{})
+
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+
+	// Fetch the ID of the new AWS access key that was created from Vault - as we want to take ownership of that one
+	accessKeyID, err := readRootAccessKeyID(ctx, vaultClient, enginePath)
+	if err != nil {
+		return "", fmt.Errorf("error reading the new access key ID from Vault: %w", err)
+	}
+
+	return accessKeyID, nil
+}
+
+// readRootAccessKeyID returns the ID of the access key that is configured as root credentials of the secret engine
+func readRootAccessKeyID(ctx context.Context, vaultClient *vault.Client, enginePath string) (string, error) {
+	vResp, err := vaultClient.Logical().ReadWithContext(ctx, fmt.Sprintf("%s/config/root", enginePath))
+	if err != nil {
+		return "", err
+	}
+	if vResp == nil {
+		return "", fmt.Errorf("the secret engine %s has no root credentials configured", enginePath)
+	}
+
+	accessKeyID, _ := vResp.Data["access_key"].(string)
+	return accessKeyID, nil
+}
+
+// deleteAccessKey deletes the given access key of the IAM user. An access key that doesn't exist (anymore) is not
+// considered to be an error.
+func deleteAccessKey(ctx context.Context, iamClient *iam.Client, username string, accessKeyID string) error {
+	_, err := iamClient.DeleteAccessKey(ctx, &iam.DeleteAccessKeyInput{
+		UserName:    aws.String(username),
+		AccessKeyId: aws.String(accessKeyID),
+	})
+
+	var notFound *iamTypes.NoSuchEntityException
+	if errors.As(err, &notFound) {
+		return nil
+	}
+
+	return err
+}
+
+// vaultClient returns a Vault client that is scoped to the given namespace. As for the Vault provider, the
+// namespace is relative to the namespace configured in the provider.
+func (r resourceAwsSecretAccessKey) vaultClient(namespace types.String) (*vault.Client, error) {
+	if namespace.Null || namespace.Value == "" {
+		return r.p.vault, nil
+	}
+
+	client, err := r.p.vault.CloneWithHeaders()
+	if err != nil {
+		return nil, err
+	}
+	client.SetToken(r.p.vault.Token())
+
+	providerNamespace := r.p.vault.Headers().Get(consts.NamespaceHeaderName)
+	client.SetNamespace(path.Join(providerNamespace, namespace.Value))
+
+	return client, nil
+}
+
+// awsSecretAccessKeyID returns the ID of the resource, which has the same format as the import identifier
+func awsSecretAccessKeyID(vaultNamespace types.String, vaultEnginePath types.String, awsIamUsername types.String) string {
+	if vaultNamespace.Null || vaultNamespace.Value == "" {
+		return fmt.Sprintf("%s:%s", vaultEnginePath.Value, awsIamUsername.Value)
+	}
+
+	return fmt.Sprintf("%s:%s:%s", vaultNamespace.Value, vaultEnginePath.Value, awsIamUsername.Value)
+}
+
+// refreshLastUsed sets the usage attributes from the last usage of the access key. Attributes that IAM doesn't know
+// (i.e. as the access key was never used, or it's not known at all) are set to null.
+func refreshLastUsed(state *AwsSecretAccessKey, lastUsed *iamTypes.AccessKeyLastUsed) {
+	state.LastUsedDate = types.String{Null: true}
+	state.LastUsedService = types.String{Null: true}
+	state.LastUsedRegion = types.String{Null: true}
+	if lastUsed == nil {
+		return
+	}
+
+	if lastUsed.LastUsedDate != nil {
+		state.LastUsedDate = types.String{Value: lastUsed.LastUsedDate.Format(time.RFC3339)}
+	}
+	// IAM returns "N/A" instead of the service and region if the access key was never used
+	if lastUsed.ServiceName != nil && *lastUsed.ServiceName != "N/A" {
+		state.LastUsedService = types.String{Value: *lastUsed.ServiceName}
+	}
+	if lastUsed.Region != nil && *lastUsed.Region != "N/A" {
+		state.LastUsedRegion = types.String{Value: *lastUsed.Region}
+	}
+}
+
+// getAwsAccessKey returns the metadata (i.e. creation date and status) of the given access key of the IAM user
+func getAwsAccessKey(ctx context.Context, iamClient *iam.Client, username string, accessKeyID string) (*iamTypes.AccessKeyMetadata, error) {
 	paginator := iam.NewListAccessKeysPaginator(iamClient, &iam.ListAccessKeysInput{UserName: aws.String(username)})
 
 	for paginator.HasMorePages() {
@@ -410,7 +1786,7 @@ func getAwsAccessKeyCreationDate(ctx context.Context, iamClient *iam.Client, use
 
 		for _, key := range keys.AccessKeyMetadata {
 			if *key.AccessKeyId == accessKeyID {
-				return key.CreateDate, nil
+				return &key, nil
 			}
 		}
 	}
@@ -418,6 +1794,14 @@ func getAwsAccessKeyCreationDate(ctx context.Context, iamClient *iam.Client, use
 	return nil, ErrAccessKeyNotFound
 }
 
+// waitForAccessKey waits until IAM lists the given access key for the user, as IAM is eventually consistent
+func waitForAccessKey(ctx context.Context, iamClient *iam.Client, username string, accessKeyID string) error {
+	return retryUntilDeadline(ctx, func() error {
+		_, err := getAwsAccessKey(ctx, iamClient, username, accessKeyID)
+		return err
+	})
+}
+
 func hasExactlyOneAccessKey(ctx context.Context, iamClient *iam.Client, username string) (bool, error) {
 	keys, err := iamClient.ListAccessKeys(ctx, &iam.ListAccessKeysInput{UserName: aws.String(username)})
 	if err != nil {
@@ -0,0 +1,268 @@
+package vaultsecure
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	iamTypes "github.com/aws/aws-sdk-go-v2/service/iam/types"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"sort"
+)
+
+// selfRotationPolicyName is the name of the inline policy that allows the IAM user to rotate its own access key
+const selfRotationPolicyName = "allow-self-rotation"
+
+// managedIAMUserAttribute returns the schema of the `manage_iam_user` attribute, which has to match
+// AwsSecretAccessKeyManagedIAMUser
+func managedIAMUserAttribute() tfsdk.Attribute {
+	return tfsdk.Attribute{
+		Optional: true,
+		Description: "If set, the IAM user is created (and deleted) by this resource, together with the inline " +
+			"policy that allows Vault to rotate its access key.",
+		Attributes: tfsdk.SingleNestedAttributes(map[string]tfsdk.Attribute{
+			"path": {
+				Type:        types.StringType,
+				Optional:    true,
+				Description: "Path of the IAM user. Defaults to `/`.",
+			},
+			"permissions_boundary": {
+				Type:        types.StringType,
+				Optional:    true,
+				Description: "ARN of the policy that is used as permissions boundary of the IAM user.",
+			},
+			"tags": {
+				Type:        types.MapType{ElemType: types.StringType},
+				Optional:    true,
+				Description: "Tags of the IAM user.",
+			},
+			"policy_statements": {
+				Type:     types.ListType{ElemType: types.StringType},
+				Optional: true,
+				Description: "Additional statements (as JSON documents, i.e. using `jsonencode`) of the inline policy " +
+					"of the IAM user.",
+			},
+		}),
+	}
+}
+
+// createManagedIAMUser creates the IAM user and its inline policy. If the policy can't be created, the IAM user
+// is deleted again.
+func (r resourceAwsSecretAccessKey) createManagedIAMUser(ctx context.Context, username string, managedUser *AwsSecretAccessKeyManagedIAMUser) error {
+	input := &iam.CreateUserInput{
+		UserName: aws.String(username),
+		Tags:     managedUser.tags(),
+	}
+	if managedUser.Path.Value != "" {
+		input.Path = aws.String(managedUser.Path.Value)
+	}
+	if managedUser.PermissionsBoundary.Value != "" {
+		input.PermissionsBoundary = aws.String(managedUser.PermissionsBoundary.Value)
+	}
+
+	user, err := r.p.iam.CreateUser(ctx, input)
+	if err != nil {
+		return fmt.Errorf("error creating the IAM user %s: %w", username, err)
+	}
+	tflog.Info(ctx, "Created IAM user", map[string]interface{}{
+		"user_name": username,
+	})
+
+	err = retryForNewIAMUser(ctx, func() error {
+		return r.putSelfRotationPolicy(ctx, username, *user.User.Arn, managedUser)
+	})
+	if err != nil {
+		if deleteErr := r.deleteManagedIAMUser(ctx, username); deleteErr != nil {
+			return fmt.Errorf("%w (the IAM user %s could not be deleted again: %v)", err, username, deleteErr)
+		}
+		return err
+	}
+
+	return nil
+}
+
+// updateManagedIAMUser applies the changes of the path, permissions boundary, tags and policy statements
+func (r resourceAwsSecretAccessKey) updateManagedIAMUser(ctx context.Context, username string, state *AwsSecretAccessKeyManagedIAMUser, plan *AwsSecretAccessKeyManagedIAMUser) error {
+	if !state.Path.Equal(plan.Path) {
+		path := plan.Path.Value
+		if path == "" {
+			path = "/"
+		}
+
+		_, err := r.p.iam.UpdateUser(ctx, &iam.UpdateUserInput{UserName: aws.String(username), NewPath: aws.String(path)})
+		if err != nil {
+			return fmt.Errorf("error updating the path of the IAM user %s: %w", username, err)
+		}
+	}
+
+	if !state.PermissionsBoundary.Equal(plan.PermissionsBoundary) {
+		var err error
+		if plan.PermissionsBoundary.Value != "" {
+			_, err = r.p.iam.PutUserPermissionsBoundary(ctx, &iam.PutUserPermissionsBoundaryInput{
+				UserName:            aws.String(username),
+				PermissionsBoundary: aws.String(plan.PermissionsBoundary.Value),
+			})
+		} else {
+			_, err = r.p.iam.DeleteUserPermissionsBoundary(ctx, &iam.DeleteUserPermissionsBoundaryInput{UserName: aws.String(username)})
+		}
+		if err != nil {
+			return fmt.Errorf("error updating the permissions boundary of the IAM user %s: %w", username, err)
+		}
+	}
+
+	if !state.Tags.Equal(plan.Tags) {
+		// Only the tags that were managed before are removed, the IAM user might have other tags (i.e. the ones
+		// describing its access keys)
+		var removedTags []string
+		for key := range state.Tags.Elems {
+			if _, ok := plan.Tags.Elems[key]; !ok {
+				removedTags = append(removedTags, key)
+			}
+		}
+		if len(removedTags) > 0 {
+			_, err := r.p.iam.UntagUser(ctx, &iam.UntagUserInput{UserName: aws.String(username), TagKeys: removedTags})
+			if err != nil {
+				return fmt.Errorf("error removing tags of the IAM user %s: %w", username, err)
+			}
+		}
+
+		if tags := plan.tags(); len(tags) > 0 {
+			_, err := r.p.iam.TagUser(ctx, &iam.TagUserInput{UserName: aws.String(username), Tags: tags})
+			if err != nil {
+				return fmt.Errorf("error tagging the IAM user %s: %w", username, err)
+			}
+		}
+	}
+
+	// The ARN of the IAM user changes with its path, so the policy is updated in that case as well
+	if !state.Path.Equal(plan.Path) || !state.PolicyStatements.Equal(plan.PolicyStatements) {
+		user, err := r.p.iam.GetUser(ctx, &iam.GetUserInput{UserName: aws.String(username)})
+		if err != nil {
+			return fmt.Errorf("error fetching the IAM user %s: %w", username, err)
+		}
+
+		err = r.putSelfRotationPolicy(ctx, username, *user.User.Arn, plan)
+		if err != nil {
+			return err
+		}
+	}
+
+	tflog.Info(ctx, "Updated IAM user", map[string]interface{}{
+		"user_name": username,
+	})
+
+	return nil
+}
+
+// retryForNewIAMUser retries the given function until the deadline (see retryUntilDeadline). As IAM is eventually
+// consistent, an IAM user that was just created might not be found yet, so NoSuchEntity is retried as well.
+func retryForNewIAMUser(ctx context.Context, retryableFunc func() error) error {
+	return retryUntilDeadline(ctx, func() error {
+		err := retryableFunc()
+
+		var notFound *iamTypes.NoSuchEntityException
+		if errors.As(err, &notFound) {
+			return &transientError{err: err}
+		}
+		return err
+	})
+}
+
+// deleteManagedIAMUser deletes the inline policy and the IAM user. The access keys of the IAM user need to be
+// deleted before. IAM users or policies that don't exist (anymore) are not considered to be an error.
+func (r resourceAwsSecretAccessKey) deleteManagedIAMUser(ctx context.Context, username string) error {
+	var notFound *iamTypes.NoSuchEntityException
+
+	_, err := r.p.iam.DeleteUserPolicy(ctx, &iam.DeleteUserPolicyInput{
+		UserName:   aws.String(username),
+		PolicyName: aws.String(selfRotationPolicyName),
+	})
+	if err != nil && !errors.As(err, &notFound) {
+		return fmt.Errorf("error deleting the policy of the IAM user %s: %w", username, err)
+	}
+
+	_, err = r.p.iam.DeleteUser(ctx, &iam.DeleteUserInput{UserName: aws.String(username)})
+	if err != nil && !errors.As(err, &notFound) {
+		return fmt.Errorf("error deleting the IAM user %s: %w", username, err)
+	}
+	tflog.Info(ctx, "Deleted IAM user", map[string]interface{}{
+		"user_name": username,
+	})
+
+	return nil
+}
+
+// putSelfRotationPolicy creates or updates the inline policy of the IAM user
+func (r resourceAwsSecretAccessKey) putSelfRotationPolicy(ctx context.Context, username string, userArn string, managedUser *AwsSecretAccessKeyManagedIAMUser) error {
+	policy, err := selfRotationPolicy(userArn, managedUser.PolicyStatements)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.p.iam.PutUserPolicy(ctx, &iam.PutUserPolicyInput{
+		UserName:       aws.String(username),
+		PolicyName:     aws.String(selfRotationPolicyName),
+		PolicyDocument: aws.String(policy),
+	})
+	if err != nil {
+		return fmt.Errorf("error writing the policy of the IAM user %s: %w", username, err)
+	}
+
+	return nil
+}
+
+// selfRotationPolicy returns the policy document that allows the IAM user to rotate its own access key (as
+// required by the rotate-root API of Vault), extended by the given statements
+func selfRotationPolicy(userArn string, statements types.List) (string, error) {
+	policyStatements := []interface{}{
+		map[string]interface{}{
+			"Effect":   "Allow",
+			"Action":   []string{"iam:CreateAccessKey", "iam:DeleteAccessKey", "iam:GetUser"},
+			"Resource": userArn,
+		},
+	}
+
+	for _, statement := range statements.Elems {
+		value, ok := statement.(types.String)
+		if !ok || value.Null || value.Unknown {
+			continue
+		}
+
+		var policyStatement json.RawMessage
+		err := json.Unmarshal([]byte(value.Value), &policyStatement)
+		if err != nil {
+			return "", fmt.Errorf("invalid policy statement %q: %w", value.Value, err)
+		}
+		policyStatements = append(policyStatements, policyStatement)
+	}
+
+	policy, err := json.Marshal(map[string]interface{}{
+		"Version":   "2012-10-17",
+		"Statement": policyStatements,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return string(policy), nil
+}
+
+// tags returns the tags of the IAM user, sorted by key
+func (m *AwsSecretAccessKeyManagedIAMUser) tags() []iamTypes.Tag {
+	var tags []iamTypes.Tag
+	for key, value := range m.Tags.Elems {
+		if value, ok := value.(types.String); ok && !value.Null && !value.Unknown {
+			tags = append(tags, iamTypes.Tag{Key: aws.String(key), Value: aws.String(value.Value)})
+		}
+	}
+
+	sort.Slice(tags, func(i, j int) bool {
+		return *tags[i].Key < *tags[j].Key
+	})
+
+	return tags
+}
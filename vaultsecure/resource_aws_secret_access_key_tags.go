@@ -0,0 +1,110 @@
+package vaultsecure
+
+import (
+	"context"
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	iamTypes "github.com/aws/aws-sdk-go-v2/service/iam/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"path"
+	"strings"
+)
+
+// accessKeyTagValuePrefix identifies the description tags of access keys that are managed by this provider. Note
+// that IAM doesn't allow commas in tag values.
+const accessKeyTagValuePrefix = "managed by vaultsecure - engine "
+
+// tagAccessKey tags the IAM user with the description of the access key, so it can be told apart from other access
+// keys (i.e. in the IAM console). IAM uses tags with the access key ID as key for the descriptions of access keys.
+//
+// Description tags of previous access keys that were managed by this provider are removed.
+func (r resourceAwsSecretAccessKey) tagAccessKey(ctx context.Context, state AwsSecretAccessKey) error {
+	username := state.AwsIamUsername.Value
+	accessKeyID := state.AwsAccessKeyID.Value
+	description := accessKeyTagValuePrefix + path.Join(state.VaultNamespace.Value, state.VaultEnginePath.Value)
+
+	tags, err := listUserTags(ctx, r.p.iam, username)
+	if err != nil {
+		return err
+	}
+
+	if tags[accessKeyID] != description {
+		_, err = r.p.iam.TagUser(ctx, &iam.TagUserInput{
+			UserName: aws.String(username),
+			Tags:     []iamTypes.Tag{{Key: aws.String(accessKeyID), Value: aws.String(description)}},
+		})
+		if err != nil {
+			return fmt.Errorf("error tagging the IAM user %s: %w", username, err)
+		}
+		tflog.Info(ctx, "Tagged IAM user with the description of the access key", map[string]interface{}{
+			"access_key_id": accessKeyID,
+		})
+	}
+
+	return r.removeStaleAccessKeyTags(ctx, username, accessKeyID, tags)
+}
+
+// updateAccessKeyTag tags the IAM user with the description of a new access key (see tagAccessKey). The description
+// is only informational, so an error (i.e. if iam:TagUser is not allowed) is only logged as a warning.
+func (r resourceAwsSecretAccessKey) updateAccessKeyTag(ctx context.Context, state AwsSecretAccessKey) {
+	err := r.tagAccessKey(ctx, state)
+	if err != nil {
+		tflog.Warn(ctx, "Could not tag the IAM user with the description of the access key", map[string]interface{}{
+			"access_key_id": state.AwsAccessKeyID.Value,
+			"error":         err.Error(),
+		})
+	}
+}
+
+// untagAccessKeys removes the description tags of all access keys that are managed by this provider, except the
+// given one
+func (r resourceAwsSecretAccessKey) untagAccessKeys(ctx context.Context, username string, exceptAccessKeyID string) error {
+	tags, err := listUserTags(ctx, r.p.iam, username)
+	if err != nil {
+		return err
+	}
+
+	return r.removeStaleAccessKeyTags(ctx, username, exceptAccessKeyID, tags)
+}
+
+func (r resourceAwsSecretAccessKey) removeStaleAccessKeyTags(ctx context.Context, username string, accessKeyID string, tags map[string]string) error {
+	var staleTags []string
+	for key, value := range tags {
+		if key != accessKeyID && strings.HasPrefix(value, accessKeyTagValuePrefix) {
+			staleTags = append(staleTags, key)
+		}
+	}
+	if len(staleTags) == 0 {
+		return nil
+	}
+
+	_, err := r.p.iam.UntagUser(ctx, &iam.UntagUserInput{UserName: aws.String(username), TagKeys: staleTags})
+	if err != nil {
+		return fmt.Errorf("error removing stale tags of the IAM user %s: %w", username, err)
+	}
+	tflog.Info(ctx, "Removed stale access key descriptions from the IAM user", map[string]interface{}{
+		"access_key_ids": strings.Join(staleTags, ", "),
+	})
+
+	return nil
+}
+
+// listUserTags returns all tags of the IAM user
+func listUserTags(ctx context.Context, iamClient *iam.Client, username string) (map[string]string, error) {
+	tags := map[string]string{}
+
+	paginator := iam.NewListUserTagsPaginator(iamClient, &iam.ListUserTagsInput{UserName: aws.String(username)})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("error listing the tags of the IAM user %s: %w", username, err)
+		}
+
+		for _, tag := range page.Tags {
+			tags[*tag.Key] = *tag.Value
+		}
+	}
+
+	return tags, nil
+}
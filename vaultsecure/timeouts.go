@@ -0,0 +1,182 @@
+package vaultsecure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/avast/retry-go/v4"
+	awsRetry "github.com/aws/aws-sdk-go-v2/aws/retry"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/smithy-go"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	vault "github.com/hashicorp/vault/api"
+	"math"
+	"net/http"
+	"time"
+)
+
+const (
+	defaultCreateTimeout = 5 * time.Minute
+	defaultReadTimeout   = 2 * time.Minute
+	defaultUpdateTimeout = 5 * time.Minute
+	defaultDeleteTimeout = 2 * time.Minute
+
+	// Terraform doesn't pass the configuration when importing a resource, so the import timeout can't be configured
+	defaultImportTimeout = 5 * time.Minute
+)
+
+// timeoutsAttribute returns the schema of the `timeouts` attribute, which has to match AwsSecretAccessKeyTimeouts
+func timeoutsAttribute() tfsdk.Attribute {
+	timeout := func(operation string, defaultTimeout time.Duration) tfsdk.Attribute {
+		return tfsdk.Attribute{
+			Type:     types.StringType,
+			Optional: true,
+			Description: fmt.Sprintf("Maximum duration of the %s operation (i.e. `10m`). Defaults to `%s`.",
+				operation, defaultTimeout),
+			Validators: []tfsdk.AttributeValidator{
+				durationValidator{},
+			},
+		}
+	}
+
+	return tfsdk.Attribute{
+		Optional: true,
+		Description: "Maximum durations of the operations. Terraform doesn't pass the configuration when importing " +
+			"a resource, so imports always use a timeout of `5m`.",
+		Attributes: tfsdk.SingleNestedAttributes(map[string]tfsdk.Attribute{
+			"create": timeout("create", defaultCreateTimeout),
+			"read":   timeout("read", defaultReadTimeout),
+			"update": timeout("update", defaultUpdateTimeout),
+			"delete": timeout("delete", defaultDeleteTimeout),
+		}),
+	}
+}
+
+func (t *AwsSecretAccessKeyTimeouts) create() time.Duration {
+	if t == nil {
+		return defaultCreateTimeout
+	}
+	return durationOrDefault(t.Create, defaultCreateTimeout)
+}
+
+func (t *AwsSecretAccessKeyTimeouts) read() time.Duration {
+	if t == nil {
+		return defaultReadTimeout
+	}
+	return durationOrDefault(t.Read, defaultReadTimeout)
+}
+
+func (t *AwsSecretAccessKeyTimeouts) update() time.Duration {
+	if t == nil {
+		return defaultUpdateTimeout
+	}
+	return durationOrDefault(t.Update, defaultUpdateTimeout)
+}
+
+func (t *AwsSecretAccessKeyTimeouts) delete() time.Duration {
+	if t == nil {
+		return defaultDeleteTimeout
+	}
+	return durationOrDefault(t.Delete, defaultDeleteTimeout)
+}
+
+// durationOrDefault returns the duration of the given attribute, or the default if it is not set. The attribute is
+// expected to be validated with durationValidator.
+func durationOrDefault(value types.String, defaultDuration time.Duration) time.Duration {
+	if value.Null || value.Unknown {
+		return defaultDuration
+	}
+
+	duration, err := time.ParseDuration(value.Value)
+	if err != nil {
+		return defaultDuration
+	}
+
+	return duration
+}
+
+// retryUntilDeadline retries the given function with an exponential backoff, until it succeeds or the context is
+// done (i.e. the timeout of the operation exceeded). Permanent errors (see isPermanentError) are not retried.
+func retryUntilDeadline(ctx context.Context, retryableFunc func() error) error {
+	var lastErr error
+	err := retry.Do(
+		func() error {
+			lastErr = retryableFunc()
+			if isPermanentError(lastErr) {
+				return retry.Unrecoverable(lastErr)
+			}
+			return lastErr
+		},
+		retry.Context(ctx),
+		retry.DelayType(retry.BackOffDelay),
+		retry.Delay(time.Second),
+		retry.MaxDelay(15*time.Second),
+		// retry-go ignores the context if the attempts are unlimited, so the deadline is limiting them instead
+		retry.Attempts(math.MaxUint32),
+		retry.LastErrorOnly(true),
+		retry.OnRetry(func(n uint, err error) {
+			tflog.Debug(ctx, "Retrying after error", map[string]interface{}{
+				"attempt": n + 1,
+				"error":   err.Error(),
+			})
+		}),
+	)
+	if err != nil && ctx.Err() != nil && lastErr != nil {
+		return fmt.Errorf("%w, last error: %v", err, lastErr)
+	}
+
+	return err
+}
+
+// transientError marks an error that is not permanent, even if isPermanentError would consider it to be (i.e. an
+// IAM user that was just created and is not found yet)
+type transientError struct {
+	err error
+}
+
+func (e *transientError) Error() string {
+	return e.err.Error()
+}
+
+func (e *transientError) Unwrap() error {
+	return e.err
+}
+
+// isPermanentError returns true for client errors of Vault and AWS (i.e. missing permissions or a secret engine that
+// doesn't exist), as retrying won't resolve them. Throttling is not considered to be permanent, neither are requests
+// that Vault rejects until its replicated state is consistent.
+//
+// Vault passes the errors of AWS on as server errors, i.e. if rotate-root fails. They are not considered to be
+// permanent either, as AWS rejects a new access key (InvalidClientTokenId) or the policy of a new IAM user
+// (AccessDenied) until IAM is consistent.
+func isPermanentError(err error) bool {
+	var transientErr *transientError
+	if errors.As(err, &transientErr) {
+		return false
+	}
+
+	var vaultErr *vault.ResponseError
+	if errors.As(err, &vaultErr) {
+		return isClientError(vaultErr.StatusCode) && vaultErr.StatusCode != http.StatusPreconditionFailed
+	}
+
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		if _, ok := awsRetry.DefaultThrottleErrorCodes[apiErr.ErrorCode()]; ok {
+			return false
+		}
+	}
+	var awsErr *awshttp.ResponseError
+	if errors.As(err, &awsErr) {
+		return isClientError(awsErr.HTTPStatusCode())
+	}
+
+	return false
+}
+
+// isClientError returns true for 4xx status codes, except for 429 (too many requests)
+func isClientError(statusCode int) bool {
+	return statusCode >= 400 && statusCode < 500 && statusCode != http.StatusTooManyRequests
+}
@@ -0,0 +1,136 @@
+package vaultsecure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/smithy-go"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+	vault "github.com/hashicorp/vault/api"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRetryUntilDeadline(t *testing.T) {
+	t.Run("succeeds after retry", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		attempts := 0
+		err := retryUntilDeadline(ctx, func() error {
+			attempts++
+			if attempts < 2 {
+				return errors.New("not consistent yet")
+			}
+			return nil
+		})
+
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		if attempts != 2 {
+			t.Errorf("expected 2 attempts, got %d", attempts)
+		}
+	})
+
+	t.Run("fails at deadline", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+
+		err := retryUntilDeadline(ctx, func() error {
+			return errors.New("not consistent yet")
+		})
+
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("expected deadline exceeded error, got: %v", err)
+		}
+		if !strings.Contains(err.Error(), "not consistent yet") {
+			t.Errorf("expected the last error to be reported, got: %v", err)
+		}
+	})
+
+	t.Run("fails on permanent error", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		attempts := 0
+		permissionDenied := &vault.ResponseError{StatusCode: http.StatusForbidden}
+		err := retryUntilDeadline(ctx, func() error {
+			attempts++
+			return permissionDenied
+		})
+
+		if err != permissionDenied {
+			t.Fatalf("expected the permanent error, got: %v", err)
+		}
+		if attempts != 1 {
+			t.Errorf("expected a single attempt, got %d", attempts)
+		}
+	})
+}
+
+func TestIsPermanentError(t *testing.T) {
+	awsError := func(statusCode int, code string) error {
+		return &smithy.OperationError{
+			ServiceID:     "IAM",
+			OperationName: "ListAccessKeys",
+			Err: &awshttp.ResponseError{
+				ResponseError: &smithyhttp.ResponseError{
+					Response: &smithyhttp.Response{Response: &http.Response{StatusCode: statusCode}},
+					Err:      &smithy.GenericAPIError{Code: code},
+				},
+			},
+		}
+	}
+
+	testCases := []struct {
+		name      string
+		err       error
+		permanent bool
+	}{
+		{"other error", errors.New("not consistent yet"), false},
+		{"access key not found", ErrAccessKeyNotFound, false},
+		{"Vault permission denied", fmt.Errorf("rotating: %w", &vault.ResponseError{StatusCode: http.StatusForbidden}), true},
+		{"Vault missing mount", &vault.ResponseError{StatusCode: http.StatusNotFound}, true},
+		{"Vault rate limit", &vault.ResponseError{StatusCode: http.StatusTooManyRequests}, false},
+		{"Vault inconsistent state", &vault.ResponseError{StatusCode: http.StatusPreconditionFailed}, false},
+		{"Vault internal error", &vault.ResponseError{StatusCode: http.StatusInternalServerError}, false},
+		{"Vault passing on an AWS error", &vault.ResponseError{
+			StatusCode: http.StatusInternalServerError,
+			Errors:     []string{"error calling CreateAccessKey: AccessDenied: User: arn:aws:iam::123456789012:user/vault is not authorized"},
+		}, false},
+		{"AWS access denied", awsError(http.StatusForbidden, "AccessDenied"), true},
+		{"AWS no such entity", awsError(http.StatusNotFound, "NoSuchEntity"), true},
+		{"transient AWS no such entity", &transientError{err: awsError(http.StatusNotFound, "NoSuchEntity")}, false},
+		{"AWS throttling", awsError(http.StatusBadRequest, "Throttling"), false},
+		{"AWS service failure", awsError(http.StatusInternalServerError, "ServiceFailure"), false},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			if permanent := isPermanentError(testCase.err); permanent != testCase.permanent {
+				t.Errorf("expected isPermanentError to be %v, got %v", testCase.permanent, permanent)
+			}
+		})
+	}
+}
+
+func TestDurationOrDefault(t *testing.T) {
+	var timeouts *AwsSecretAccessKeyTimeouts
+	if timeouts.create() != defaultCreateTimeout {
+		t.Errorf("expected the default timeout without a timeouts attribute, got %s", timeouts.create())
+	}
+
+	timeouts = &AwsSecretAccessKeyTimeouts{}
+	timeouts.Create.Value = "10m"
+	timeouts.Delete.Null = true
+	if timeouts.create() != 10*time.Minute {
+		t.Errorf("expected the configured timeout, got %s", timeouts.create())
+	}
+	if timeouts.delete() != defaultDeleteTimeout {
+		t.Errorf("expected the default timeout for a null value, got %s", timeouts.delete())
+	}
+}
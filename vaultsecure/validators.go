@@ -0,0 +1,72 @@
+package vaultsecure
+
+import (
+	"context"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"strings"
+	"time"
+)
+
+// durationValidator checks that a string attribute contains a valid duration (i.e. `90m` or `2160h`)
+type durationValidator struct{}
+
+func (v durationValidator) Description(_ context.Context) string {
+	return "value must be a duration (i.e. `90m` or `2160h`)"
+}
+
+func (v durationValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v durationValidator) Validate(ctx context.Context, req tfsdk.ValidateAttributeRequest, resp *tfsdk.ValidateAttributeResponse) {
+	value, ok := req.AttributeConfig.(types.String)
+	if !ok || value.Null || value.Unknown {
+		return
+	}
+
+	duration, err := time.ParseDuration(value.Value)
+	if err == nil && duration <= 0 {
+		err = fmt.Errorf("duration must be positive")
+	}
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(
+			req.AttributePath,
+			"Invalid duration",
+			fmt.Sprintf("The value %q is not a valid duration: %v", value.Value, err),
+		)
+	}
+}
+
+// stringOneOfValidator checks that a string attribute contains one of the given values
+type stringOneOfValidator struct {
+	values []string
+}
+
+func (v stringOneOfValidator) Description(_ context.Context) string {
+	return fmt.Sprintf("value must be one of: %s", strings.Join(v.values, ", "))
+}
+
+func (v stringOneOfValidator) MarkdownDescription(_ context.Context) string {
+	return fmt.Sprintf("value must be one of: `%s`", strings.Join(v.values, "`, `"))
+}
+
+func (v stringOneOfValidator) Validate(ctx context.Context, req tfsdk.ValidateAttributeRequest, resp *tfsdk.ValidateAttributeResponse) {
+	value, ok := req.AttributeConfig.(types.String)
+	if !ok || value.Null || value.Unknown {
+		return
+	}
+
+	for _, allowed := range v.values {
+		if value.Value == allowed {
+			return
+		}
+	}
+
+	resp.Diagnostics.AddAttributeError(
+		req.AttributePath,
+		"Invalid value",
+		fmt.Sprintf("The value %q is not valid, %s.", value.Value, v.Description(ctx)),
+	)
+}
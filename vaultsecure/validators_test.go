@@ -0,0 +1,70 @@
+package vaultsecure
+
+import (
+	"context"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"testing"
+)
+
+func TestDurationValidator(t *testing.T) {
+	testCases := map[string]struct {
+		value     types.String
+		expectErr bool
+	}{
+		"null":     {value: types.String{Null: true}},
+		"unknown":  {value: types.String{Unknown: true}},
+		"hours":    {value: types.String{Value: "2160h"}},
+		"combined": {value: types.String{Value: "1h30m"}},
+		"days":     {value: types.String{Value: "90d"}, expectErr: true},
+		"zero":     {value: types.String{Value: "0s"}, expectErr: true},
+		"negative": {value: types.String{Value: "-1h"}, expectErr: true},
+		"empty":    {value: types.String{Value: ""}, expectErr: true},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			req := tfsdk.ValidateAttributeRequest{
+				AttributePath:   tftypes.NewAttributePath().WithAttributeName("test"),
+				AttributeConfig: testCase.value,
+			}
+			resp := tfsdk.ValidateAttributeResponse{}
+
+			durationValidator{}.Validate(context.Background(), req, &resp)
+
+			if resp.Diagnostics.HasError() != testCase.expectErr {
+				t.Errorf("expected error: %t, got diagnostics: %v", testCase.expectErr, resp.Diagnostics)
+			}
+		})
+	}
+}
+
+func TestStringOneOfValidator(t *testing.T) {
+	testCases := map[string]struct {
+		value     types.String
+		expectErr bool
+	}{
+		"null":    {value: types.String{Null: true}},
+		"unknown": {value: types.String{Unknown: true}},
+		"valid":   {value: types.String{Value: "retain"}},
+		"invalid": {value: types.String{Value: "keep"}, expectErr: true},
+		"case":    {value: types.String{Value: "Retain"}, expectErr: true},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			req := tfsdk.ValidateAttributeRequest{
+				AttributePath:   tftypes.NewAttributePath().WithAttributeName("test"),
+				AttributeConfig: testCase.value,
+			}
+			resp := tfsdk.ValidateAttributeResponse{}
+
+			stringOneOfValidator{values: []string{"delete", "retain"}}.Validate(context.Background(), req, &resp)
+
+			if resp.Diagnostics.HasError() != testCase.expectErr {
+				t.Errorf("expected error: %t, got diagnostics: %v", testCase.expectErr, resp.Diagnostics)
+			}
+		})
+	}
+}